@@ -81,7 +81,7 @@ type AbsInfo struct {
 func (d *Device) AbsoluteAxes() Bitset {
 	bs := NewBitset(AbsMax)
 	buf := bs.Bytes()
-	ioctl(d.fd.Fd(), _EVIOCGBIT(EvAbsolute, len(buf)), unsafe.Pointer(&buf[0]))
+	d.ioctl(_EVIOCGBIT(EvAbsolute, len(buf)), unsafe.Pointer(&buf[0]))
 	return bs
 }
 
@@ -93,6 +93,6 @@ func (d *Device) AbsoluteAxes() Bitset {
 // This is only applicable to devices with EvAbsolute event support.
 func (d *Device) AbsoluteInfo(axis int) AbsInfo {
 	var abs AbsInfo
-	ioctl(d.fd.Fd(), _EVIOCGABS(axis), unsafe.Pointer(&abs))
+	d.ioctl(_EVIOCGABS(axis), unsafe.Pointer(&abs))
 	return abs
 }
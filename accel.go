@@ -0,0 +1,223 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import (
+	"math"
+	"time"
+)
+
+// DefaultDPI is the resolution pointer speeds are normalized to.
+const DefaultDPI = 1000
+
+// Limits on the time between two motion frames, as used to compute
+// the pointer speed. A longer pause counts as AccelMaxInterval; the
+// movement is starting from rest.
+const (
+	AccelMinInterval = time.Millisecond
+	AccelMaxInterval = 100 * time.Millisecond
+)
+
+// AccelProfile maps the speed of a pointer onto the factor its motion
+// is multiplied by. The speed is in units per millisecond, where a unit
+// is the distance a DefaultDPI device reports as 1; i.e. 1/1000 inch.
+type AccelProfile func(speed float64) float64
+
+// AccelFlat returns a profile which multiplies all motion by the
+// same factor, regardless of speed.
+func AccelFlat(factor float64) AccelProfile {
+	return func(float64) float64 {
+		return factor
+	}
+}
+
+// AccelAdaptive returns the adaptive profile used by libinput for mice.
+// Slow movements are decelerated, for precision. Beyond a threshold,
+// the factor increases linearly with speed, up to a maximum. The
+// sensitivity is in the range [-1, 1]; 0 is libinput's default.
+func AccelAdaptive(sensitivity float64) AccelProfile {
+	sensitivity = clamp(sensitivity, -1, 1)
+
+	threshold := math.Max(0.4-0.25*sensitivity, 0.2)
+	max := 2 + 1.5*sensitivity
+	incline := 1.1 + 0.75*sensitivity
+
+	return func(speed float64) float64 {
+		slow := math.Min(1, 0.3+speed*10)
+		fast := 1 + (speed-threshold)*incline
+
+		if fast > 1 {
+			return math.Min(max, fast)
+		}
+
+		return math.Min(max, slow)
+	}
+}
+
+// AccelCustom returns a profile defined by a list of output speeds,
+// for the input speeds 0, step, 2*step, etc. Speeds in between are
+// interpolated; speeds beyond the last point are extrapolated from
+// the last two points. This matches libinput's custom profile.
+func AccelCustom(step float64, points ...float64) AccelProfile {
+	if step <= 0 || len(points) < 2 {
+		return AccelFlat(1)
+	}
+
+	return func(speed float64) float64 {
+		if speed <= 0 {
+			return (points[1] - points[0]) / step
+		}
+
+		i := int(speed / step)
+		if i > len(points)-2 {
+			i = len(points) - 2
+		}
+
+		x := speed/step - float64(i)
+		out := points[i] + (points[i+1]-points[i])*x
+		return out / speed
+	}
+}
+
+// Accel is a Filter which applies pointer acceleration to relative
+// motion (RelX and RelY). The speed of the pointer is computed from
+// the event timestamps and the resolution of the device. Motion which
+// falls short of a whole unit after scaling, is carried over to the
+// next frame. Other events pass through unchanged.
+//
+// The configuration must not be changed once processing has started.
+type Accel struct {
+	// Profile determines the factor by which motion is multiplied.
+	// It defaults to AccelAdaptive(0).
+	Profile AccelProfile
+
+	// DPI is the resolution of the device, in counts per inch.
+	// Motion is normalized to DefaultDPI, which is also the default.
+	DPI int
+
+	last    time.Duration // Time of the previous motion frame.
+	speed   float64       // Speed at the previous motion frame.
+	started bool          // There was a previous motion frame.
+	remX    float64       // Motion carried over to the next frame.
+	remY    float64
+}
+
+// NewAccel creates an acceleration filter with the given
+// profile, for a device with the given resolution.
+func NewAccel(profile AccelProfile, dpi int) *Accel {
+	return &Accel{Profile: profile, DPI: dpi}
+}
+
+// Run implements Filter.
+func (a *Accel) Run(in <-chan Frame, out chan<- Frame) {
+	FilterFunc(func(f Frame) []Frame {
+		if f = a.apply(f); len(f) == 0 {
+			return nil
+		}
+		return []Frame{f}
+	}).Run(in, out)
+}
+
+// apply accelerates the motion in a single frame. It returns
+// an empty frame if nothing remains.
+func (a *Accel) apply(f Frame) Frame {
+	var dx, dy float64
+	var t time.Duration
+	var motion bool
+
+	for _, e := range f {
+		if e.Type != EvRelative || e.Code != RelX && e.Code != RelY {
+			continue
+		}
+
+		if !motion {
+			motion = true
+			t = eventTime(e)
+		}
+
+		if e.Code == RelX {
+			dx += float64(e.Value)
+		} else {
+			dy += float64(e.Value)
+		}
+	}
+
+	if !motion {
+		return f
+	}
+
+	// Normalize to DefaultDPI.
+	if a.DPI > 0 {
+		dx *= DefaultDPI / float64(a.DPI)
+		dy *= DefaultDPI / float64(a.DPI)
+	}
+
+	factor := a.profile()(a.velocity(t, math.Hypot(dx, dy)))
+
+	a.remX += dx * factor
+	a.remY += dy * factor
+
+	x := math.Trunc(a.remX)
+	y := math.Trunc(a.remY)
+	a.remX -= x
+	a.remY -= y
+
+	// The motion takes the place of the first motion event.
+	out := make(Frame, 0, len(f))
+	for _, e := range f {
+		if e.Type != EvRelative || e.Code != RelX && e.Code != RelY {
+			out = append(out, e)
+			continue
+		}
+
+		if !motion {
+			continue
+		}
+
+		motion = false
+
+		for code, v := range [2]float64{RelX: x, RelY: y} {
+			if v != 0 {
+				out = append(out, Event{Time: e.Time, Type: EvRelative, Code: uint16(code), Value: int32(v)})
+			}
+		}
+	}
+
+	return out
+}
+
+// velocity returns the speed of the pointer, for a motion frame
+// at time t, covering the given distance. The speed is averaged
+// with that of the previous frame, to smooth out jitter in the
+// timestamps.
+func (a *Accel) velocity(t time.Duration, dist float64) float64 {
+	dt := t - a.last
+	moving := a.started && dt <= AccelMaxInterval
+
+	if !moving {
+		dt = AccelMaxInterval
+	}
+
+	if dt < AccelMinInterval {
+		dt = AccelMinInterval
+	}
+
+	speed := dist / (float64(dt) / float64(time.Millisecond))
+
+	if moving {
+		speed = (speed + a.speed) / 2
+	}
+
+	a.last = t
+	a.speed = speed
+	a.started = true
+	return speed
+}
+
+func (a *Accel) profile() AccelProfile {
+	if a.Profile != nil {
+		return a.Profile
+	}
+	return AccelAdaptive(0)
+}
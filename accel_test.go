@@ -0,0 +1,116 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAccelProfiles(t *testing.T) {
+	adaptive := AccelAdaptive(0)
+	custom := AccelCustom(1, 0, 1, 4)
+
+	tests := []struct {
+		name    string
+		profile AccelProfile
+		speed   float64
+		want    float64
+	}{
+		{"flat", AccelFlat(1.5), 3, 1.5},
+		{"adaptive at rest", adaptive, 0, 0.3},
+		{"adaptive slow", adaptive, 0.05, 0.8},
+		{"adaptive threshold", adaptive, 0.4, 1},
+		{"adaptive fast", adaptive, 1, 1.66},
+		{"adaptive max", adaptive, 10, 2},
+		{"custom at rest", custom, 0, 1},
+		{"custom", custom, 1.5, 2.5 / 1.5},
+		{"custom extrapolated", custom, 3, 7.0 / 3},
+	}
+
+	for _, tt := range tests {
+		if got := tt.profile(tt.speed); math.Abs(got-tt.want) > 1e-9 {
+			t.Fatalf("%s: Want %v, have %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestAccel(t *testing.T) {
+	tests := []struct {
+		name string
+		dpi  int
+		in   []Frame
+		want []Frame
+	}{
+		{
+			name: "scaled",
+			in:   []Frame{{eventAt(EvRelative, RelX, 0, 3), eventAt(EvRelative, RelY, 0, -2)}},
+			want: []Frame{{eventAt(EvRelative, RelX, 0, 6), eventAt(EvRelative, RelY, 0, -4)}},
+		},
+		{
+			name: "normalized",
+			dpi:  4000,
+			in:   []Frame{{eventAt(EvRelative, RelX, 0, 8)}},
+			want: []Frame{{eventAt(EvRelative, RelX, 0, 4)}},
+		},
+		{
+			name: "remainder",
+			dpi:  8000,
+			in: []Frame{
+				{eventAt(EvRelative, RelX, 0, 3)},
+				{eventAt(EvRelative, RelX, 8, 3), eventAt(EvRelative, RelY, 8, 2)},
+				{eventAt(EvRelative, RelX, 16, -3)},
+				{eventAt(EvRelative, RelY, 24, 2)},
+			},
+			want: []Frame{
+				{},
+				{eventAt(EvRelative, RelX, 8, 1)},
+				{},
+				{eventAt(EvRelative, RelY, 24, 1)},
+			},
+		},
+		{
+			name: "other events",
+			in:   []Frame{{key(BtnLeft, 1), eventAt(EvRelative, RelWheel, 0, 1), eventAt(EvRelative, RelX, 0, 1)}},
+			want: []Frame{{key(BtnLeft, 1), eventAt(EvRelative, RelWheel, 0, 1), eventAt(EvRelative, RelX, 0, 2)}},
+		},
+	}
+
+	for _, tt := range tests {
+		a := NewAccel(AccelFlat(2), tt.dpi)
+
+		var got []Frame
+		for _, f := range tt.in {
+			got = append(got, a.apply(f))
+		}
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("%s: Want %v, have %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestAccelVelocity(t *testing.T) {
+	var a Accel
+
+	steps := []struct {
+		ms   int
+		dist float64
+		want float64
+	}{
+		{0, 10, 0.1},    // From rest.
+		{10, 10, 0.55},  // Averaged with the previous frame.
+		{10, 10, 5.275}, // Same timestamp; counts as AccelMinInterval.
+		{300, 10, 0.1},  // From rest again.
+	}
+
+	for i, s := range steps {
+		got := a.velocity(time.Duration(s.ms)*time.Millisecond, s.dist)
+		if math.Abs(got-s.want) > 1e-9 {
+			t.Fatalf("Step %d: Want %v, have %v", i, s.want, got)
+		}
+	}
+}
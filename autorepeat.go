@@ -0,0 +1,167 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import "time"
+
+// Default repeat rate for Autorepeat. These match the kernel's defaults.
+const (
+	DefaultRepeatDelay  = 250 * time.Millisecond
+	DefaultRepeatPeriod = 33 * time.Millisecond
+)
+
+// RepeatRate describes how a key repeats: the delay between pressing it
+// and the first repeat, and the period between subsequent repeats.
+type RepeatRate struct {
+	Delay  time.Duration
+	Period time.Duration
+}
+
+// Autorepeat is a Filter which generates key repeats (events with
+// value 2), for devices which do not do so themselves; or for keys
+// which are re-emitted through a virtual device, after the original
+// device has been grabbed.
+//
+// Like the kernel, it only repeats the key which was pressed last.
+// Repeating stops when that key is released, or when another key
+// is pressed. Mouse, joystick and gamepad buttons are not repeated.
+//
+// Repeats are timed from the event timestamps, and carry the time at
+// which they are due. Other events pass through unchanged, as do the
+// repeats produced by the device itself; unless they are suppressed.
+//
+// The configuration must not be changed once processing has started.
+type Autorepeat struct {
+	// Delay and Period set the repeat rate for all keys.
+	// They default to DefaultRepeatDelay and DefaultRepeatPeriod.
+	Delay  time.Duration
+	Period time.Duration
+
+	// Keys overrides the repeat rate for individual keys.
+	// A zero Period disables repeating for the key.
+	Keys map[int]RepeatRate
+
+	// Suppress lists the keys whose repeats, as produced by the
+	// device, are dropped. SuppressAll drops them for all keys.
+	Suppress    []int
+	SuppressAll bool
+
+	code int           // Key being repeated; 0 if none.
+	rate RepeatRate    // Repeat rate of that key.
+	next time.Duration // Time of its next repeat.
+}
+
+// NewAutorepeat creates a filter which repeats keys at the rate
+// configured for the given device (see Device.RepeatState). The
+// defaults are used if the device reports no rate.
+func NewAutorepeat(dev *Device) *Autorepeat {
+	delay, period := dev.RepeatState()
+
+	return &Autorepeat{
+		Delay:  time.Duration(delay) * time.Millisecond,
+		Period: time.Duration(period) * time.Millisecond,
+	}
+}
+
+// Feed passes a single event to the filter and returns the events to
+// pass on: the repeats which were due before it, followed by the
+// event itself, unless it is suppressed.
+func (a *Autorepeat) Feed(e Event) []Event {
+	out := a.Tick(eventTime(e))
+
+	if e.Type != EvKeys {
+		return append(out, e)
+	}
+
+	code := int(e.Code)
+
+	switch e.Value {
+	case 0:
+		if code == a.code {
+			a.code = 0
+		}
+
+	case 1:
+		a.code = 0
+
+		if rate, ok := a.rateOf(code); ok {
+			a.code = code
+			a.rate = rate
+			a.next = eventTime(e) + rate.Delay
+		}
+
+	default:
+		if a.SuppressAll || hasKey(a.Suppress, code) {
+			return out
+		}
+	}
+
+	return append(out, e)
+}
+
+// Tick tells the filter the given time has come and returns the
+// repeats which are due by then. The time is in the same clock as
+// the event timestamps. See Deadline.
+func (a *Autorepeat) Tick(now time.Duration) []Event {
+	var out []Event
+
+	for a.repeating() && a.next <= now {
+		e := timeEvent(a.next)
+		e.Type = EvKeys
+		e.Code = uint16(a.code)
+		e.Value = 2
+
+		out = append(out, e)
+		a.next += a.rate.Period
+	}
+
+	return out
+}
+
+// Deadline returns the time at which the next repeat is due, if any.
+// Tick should be called at that time.
+func (a *Autorepeat) Deadline() (time.Duration, bool) {
+	return a.next, a.repeating()
+}
+
+// Run implements Filter.
+func (a *Autorepeat) Run(in <-chan Frame, out chan<- Frame) {
+	runTimed(a, in, out, nil)
+}
+
+// repeating returns true if a key is being repeated.
+func (a *Autorepeat) repeating() bool {
+	return a.code > 0
+}
+
+// rateOf returns the repeat rate of the given key,
+// or false if it does not repeat.
+func (a *Autorepeat) rateOf(code int) (RepeatRate, bool) {
+	if code <= 0 || isButton(code) {
+		return RepeatRate{}, false
+	}
+
+	if rate, ok := a.Keys[code]; ok {
+		return rate, rate.Period > 0
+	}
+
+	rate := RepeatRate{Delay: a.Delay, Period: a.Period}
+	if rate.Delay <= 0 {
+		rate.Delay = DefaultRepeatDelay
+	}
+
+	if rate.Period <= 0 {
+		rate.Period = DefaultRepeatPeriod
+	}
+
+	return rate, true
+}
+
+// isButton returns true if the given code is a mouse, joystick,
+// gamepad or tablet button, rather than a key.
+func isButton(code int) bool {
+	return code >= BtnMisc && code < KeyOk ||
+		code >= BtnDpadUp && code <= BtnDpadRight ||
+		code >= BtnTriggerHappy && code <= BtnTriggerHappy40
+}
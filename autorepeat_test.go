@@ -0,0 +1,96 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAutorepeat(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []Event
+		tick   int // Milliseconds; 0 means no tick.
+		want   string
+	}{
+		{
+			name:   "tap",
+			events: []Event{eventAt(EvKeys, KeyA, 0, 1), eventAt(EvKeys, KeyA, 90, 0)},
+			want:   "KeyA 1; KeyA 0",
+		},
+		{
+			name:   "hold",
+			events: []Event{eventAt(EvKeys, KeyA, 0, 1)},
+			tick:   250,
+			want:   "KeyA 1; KeyA 2@100; KeyA 2@150; KeyA 2@200; KeyA 2@250",
+		},
+		{
+			name:   "release",
+			events: []Event{eventAt(EvKeys, KeyA, 0, 1), eventAt(EvKeys, KeyA, 170, 0)},
+			tick:   300,
+			want:   "KeyA 1; KeyA 2@100; KeyA 2@150; KeyA 0",
+		},
+		{
+			name:   "other key",
+			events: []Event{eventAt(EvKeys, KeyA, 0, 1), eventAt(EvKeys, KeyB, 120, 1), eventAt(EvKeys, KeyA, 130, 0)},
+			tick:   250,
+			want:   "KeyA 1; KeyA 2@100; KeyB 1; KeyA 0; KeyB 2@220",
+		},
+		{
+			name:   "other key released",
+			events: []Event{eventAt(EvKeys, KeyLeftShift, 0, 1), eventAt(EvKeys, KeyA, 10, 1), eventAt(EvKeys, KeyLeftShift, 20, 0)},
+			tick:   150,
+			want:   "KeyLeftShift 1; KeyA 1; KeyLeftShift 0; KeyA 2@110",
+		},
+		{
+			name:   "override",
+			events: []Event{eventAt(EvKeys, KeyBackSpace, 0, 1)},
+			tick:   120,
+			want:   "KeyBackSpace 1; KeyBackSpace 2@50; KeyBackSpace 2@70; KeyBackSpace 2@90; KeyBackSpace 2@110",
+		},
+		{
+			name:   "disabled",
+			events: []Event{eventAt(EvKeys, KeyLeftShift, 0, 1)},
+			tick:   500,
+			want:   "KeyLeftShift 1",
+		},
+		{
+			name:   "button",
+			events: []Event{eventAt(EvKeys, BtnLeft, 0, 1)},
+			tick:   500,
+			want:   "BtnLeft 1",
+		},
+		{
+			name:   "suppressed",
+			events: []Event{eventAt(EvKeys, KeyC, 0, 1), eventAt(EvKeys, KeyC, 40, 2), eventAt(EvKeys, KeyD, 40, 2)},
+			want:   "KeyC 1; KeyD 2@40",
+		},
+	}
+
+	for _, tt := range tests {
+		a := &Autorepeat{
+			Delay:  100 * time.Millisecond,
+			Period: 50 * time.Millisecond,
+			Keys: map[int]RepeatRate{
+				KeyBackSpace: {Delay: 50 * time.Millisecond, Period: 20 * time.Millisecond},
+				KeyLeftShift: {},
+			},
+			Suppress: []int{KeyC},
+		}
+
+		var got []Event
+		for _, e := range tt.events {
+			got = append(got, a.Feed(e)...)
+		}
+
+		if tt.tick > 0 {
+			got = append(got, a.Tick(time.Duration(tt.tick)*time.Millisecond)...)
+		}
+
+		if s := eventTrace(got); s != tt.want {
+			t.Fatalf("%s: Want %q, have %q", tt.name, tt.want, s)
+		}
+	}
+}
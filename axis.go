@@ -0,0 +1,345 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import (
+	"math"
+	"sort"
+)
+
+// AxisConfig describes how an absolute axis is conditioned.
+type AxisConfig struct {
+	// Info holds the range of the raw values, as reported by
+	// Device.AbsoluteInfo. Minimum and Maximum may be changed
+	// to calibrate the axis.
+	Info AbsInfo
+
+	// Trigger marks an axis which rests at its minimum, such as
+	// a trigger or pedal. It is normalized to [0, 1], rather
+	// than [-1, 1].
+	Trigger bool
+
+	// Deadzone is the part of the range, around the resting
+	// position, which reads as 0. It is a fraction of the distance
+	// to the end of the range. Zero means the axis' Flat is used.
+	Deadzone float64
+
+	// Saturation is the fraction of the distance to the end of the
+	// range, beyond which the axis reads as fully deflected. This
+	// makes up for sticks which never quite reach their limits.
+	// Zero means 1.
+	Saturation float64
+
+	// Curve shapes the response of the axis, beyond the deadzone.
+	// The default is CurveLinear. CurveEaseIn gives more precision
+	// for small movements.
+	Curve Curve
+
+	// Invert reverses the direction of the axis.
+	Invert bool
+}
+
+// deadzone returns the size of the deadzone, as a fraction.
+func (c *AxisConfig) deadzone() float64 {
+	if c.Deadzone > 0 {
+		return c.Deadzone
+	}
+
+	span := float64(c.Info.Maximum) - float64(c.Info.Minimum)
+	if !c.Trigger {
+		span /= 2
+	}
+
+	if span <= 0 {
+		return 0
+	}
+
+	return float64(c.Info.Flat) / span
+}
+
+// shape applies the deadzone, saturation and curve to the
+// given distance from the resting position, in [0, 1].
+func (c *AxisConfig) shape(v float64) float64 {
+	dz := c.deadzone()
+	sat := c.Saturation
+	if sat <= 0 {
+		sat = 1
+	}
+
+	if v <= dz {
+		return 0
+	}
+
+	if sat <= dz {
+		return 1
+	}
+
+	v = math.Min(1, (v-dz)/(sat-dz))
+
+	if c.Curve != nil {
+		v = c.Curve(v)
+	}
+
+	return v
+}
+
+// invert applies the inversion, if set.
+func (c *AxisConfig) invert(v float64) float64 {
+	switch {
+	case !c.Invert:
+		return v
+	case c.Trigger:
+		return 1 - v
+	default:
+		return -v
+	}
+}
+
+// AxisProcessor conditions the values of absolute axes, such as those
+// of joysticks and gamepads: it normalizes them, applies deadzones,
+// response curves and inversion.
+//
+// The axes of a stick can be paired. Their deadzone is then radial;
+// it applies to the distance of the stick from its centre, rather
+// than to each axis on its own. This keeps diagonal movements smooth.
+// Unpaired axes have an axial deadzone.
+//
+// As a Filter, it replaces the values of the axes it knows with the
+// conditioned values, scaled to the output range. Other events pass
+// through unchanged. Clone creates a matching virtual device.
+//
+// The configuration must not be changed once processing has started.
+type AxisProcessor struct {
+	// Axes holds the configuration of the axes to condition.
+	Axes map[int]AxisConfig
+
+	// Sticks lists the pairs of axes, X and Y, which make up a stick.
+	// The deadzone, saturation and curve of the X axis apply to both.
+	Sticks [][2]int
+
+	// Output is the range conditioned values are scaled to, when used
+	// as a Filter. If it is empty, each axis keeps its own range.
+	Output AbsInfo
+
+	raw map[int]int32 // Most recent raw values.
+}
+
+// NewAxisProcessor creates a processor for the axes of the given device,
+// using the ranges and flat sections it reports. The left and right
+// sticks (AbsX/AbsY and AbsRX/AbsRY) are paired, if present.
+// Multi-touch axes are left alone.
+func NewAxisProcessor(dev *Device) *AxisProcessor {
+	p := &AxisProcessor{Axes: make(map[int]AxisConfig)}
+	axes := dev.AbsoluteAxes()
+
+	for axis := 0; axis <= AbsMisc; axis++ {
+		if axes.Test(axis) {
+			p.Axes[axis] = AxisConfig{Info: dev.AbsoluteInfo(axis)}
+		}
+	}
+
+	for _, s := range [][2]int{{AbsX, AbsY}, {AbsRX, AbsRY}} {
+		if axes.Test(s[0]) && axes.Test(s[1]) {
+			p.Sticks = append(p.Sticks, s)
+		}
+	}
+
+	return p
+}
+
+// Normalize maps a raw value of the given axis onto [-1, 1], or
+// [0, 1] for triggers. No deadzone, curve or inversion is applied.
+func (p *AxisProcessor) Normalize(axis int, raw int32) float64 {
+	c := p.Axes[axis]
+	span := float64(c.Info.Maximum) - float64(c.Info.Minimum)
+	if span <= 0 {
+		return 0
+	}
+
+	v := (float64(raw) - float64(c.Info.Minimum)) / span
+	if c.Trigger {
+		return clamp(v, 0, 1)
+	}
+
+	return clamp(2*v-1, -1, 1)
+}
+
+// Set records a new raw value for the given axis.
+func (p *AxisProcessor) Set(axis int, raw int32) {
+	if p.raw == nil {
+		p.raw = make(map[int]int32)
+	}
+
+	p.raw[axis] = raw
+}
+
+// Value returns the conditioned value of the given axis, in [-1, 1],
+// or [0, 1] for triggers. It is 0 for axes which are not configured.
+func (p *AxisProcessor) Value(axis int) float64 {
+	c, ok := p.Axes[axis]
+	if !ok {
+		return 0
+	}
+
+	s, i := p.stick(axis)
+	if i < 0 {
+		v := p.Normalize(axis, p.rawValue(axis))
+		if c.Trigger {
+			return c.invert(c.shape(v))
+		}
+
+		return c.invert(math.Copysign(c.shape(math.Abs(v)), v))
+	}
+
+	x := p.Normalize(s[0], p.rawValue(s[0]))
+	y := p.Normalize(s[1], p.rawValue(s[1]))
+	v := [2]float64{x, y}[i]
+
+	// Scale the vector, to keep its direction.
+	if dist := math.Hypot(x, y); dist > 0 {
+		xc := p.Axes[s[0]]
+		v *= xc.shape(math.Min(1, dist)) / dist
+	}
+
+	return c.invert(v)
+}
+
+// Run implements Filter.
+func (p *AxisProcessor) Run(in <-chan Frame, out chan<- Frame) {
+	FilterFunc(func(f Frame) []Frame {
+		if f = p.apply(f); len(f) == 0 {
+			return nil
+		}
+		return []Frame{f}
+	}).Run(in, out)
+}
+
+// Clone creates a virtual clone of the given device, with the output
+// ranges of the processed axes. Their flat and fuzz are cleared, since
+// the conditioned values need no further treatment. Grab the device
+// and run the processor in a pipeline from it to the clone, to make
+// applications see the conditioned values only.
+func (p *AxisProcessor) Clone(dev *Device) (*VirtualDevice, error) {
+	abs := make(map[int]AbsInfo)
+
+	for axis := range p.Axes {
+		info := p.outputInfo(axis)
+		info.Value = p.output(axis)
+		abs[axis] = info
+	}
+
+	return CloneDevice(dev, CloneOptions{Absolute: abs})
+}
+
+// copy returns a copy of the processor's configuration, which can be
+// changed without affecting p. Recorded raw values are not copied.
+func (p *AxisProcessor) copy() *AxisProcessor {
+	c := &AxisProcessor{
+		Axes:   make(map[int]AxisConfig, len(p.Axes)),
+		Sticks: append([][2]int(nil), p.Sticks...),
+		Output: p.Output,
+	}
+
+	for axis, cfg := range p.Axes {
+		c.Axes[axis] = cfg
+	}
+
+	return c
+}
+
+// apply conditions the axes in a single frame. The new values of the
+// axes which changed, and those of the sticks they belong to, take the
+// place of the original axis events. It returns an empty frame if
+// nothing remains.
+func (p *AxisProcessor) apply(f Frame) Frame {
+	var changed []int
+	var last Event
+
+	out := make(Frame, 0, len(f))
+	for _, e := range f {
+		axis := int(e.Code)
+
+		if _, ok := p.Axes[axis]; e.Type != EvAbsolute || !ok {
+			out = append(out, e)
+			continue
+		}
+
+		p.Set(axis, e.Value)
+		last = e
+
+		for _, a := range p.affected(axis) {
+			if !hasKey(changed, a) {
+				changed = append(changed, a)
+			}
+		}
+	}
+
+	sort.Ints(changed)
+
+	for _, axis := range changed {
+		out = append(out, Event{
+			Time:  last.Time,
+			Type:  EvAbsolute,
+			Code:  uint16(axis),
+			Value: p.output(axis),
+		})
+	}
+
+	return out
+}
+
+// affected returns the axes whose value depends on the given axis.
+func (p *AxisProcessor) affected(axis int) []int {
+	if s, i := p.stick(axis); i >= 0 {
+		return s[:]
+	}
+	return []int{axis}
+}
+
+// stick returns the stick the given axis belongs to, along
+// with its index in it; or -1 if it does not belong to one.
+func (p *AxisProcessor) stick(axis int) ([2]int, int) {
+	for _, s := range p.Sticks {
+		for i, a := range s {
+			if a == axis {
+				return s, i
+			}
+		}
+	}
+
+	return [2]int{}, -1
+}
+
+// rawValue returns the most recent raw value of the given axis.
+func (p *AxisProcessor) rawValue(axis int) int32 {
+	if v, ok := p.raw[axis]; ok {
+		return v
+	}
+
+	return p.Axes[axis].Info.Value
+}
+
+// outputInfo returns the output range of the given axis.
+func (p *AxisProcessor) outputInfo(axis int) AbsInfo {
+	info := p.Output
+	if info.Maximum <= info.Minimum {
+		info = p.Axes[axis].Info
+	}
+
+	info.Flat = 0
+	info.Fuzz = 0
+	return info
+}
+
+// output returns the conditioned value of the given axis,
+// scaled to its output range.
+func (p *AxisProcessor) output(axis int) int32 {
+	info := p.outputInfo(axis)
+
+	if p.Axes[axis].Trigger {
+		return triggerValue(p.Value(axis), info)
+	}
+
+	return stickValue(p.Value(axis), info)
+}
@@ -0,0 +1,116 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestAxisProcessor(t *testing.T) {
+	stick := AbsInfo{Minimum: -100, Maximum: 100}
+	flat := AbsInfo{Minimum: -100, Maximum: 100, Flat: 10}
+	trigger := AbsInfo{Minimum: 0, Maximum: 200}
+
+	p := &AxisProcessor{
+		Axes: map[int]AxisConfig{
+			AbsX:        {Info: stick, Deadzone: 0.2},
+			AbsY:        {Info: stick, Invert: true},
+			AbsRX:       {Info: stick, Deadzone: 0.2},
+			AbsRY:       {Info: stick, Deadzone: 0.1, Saturation: 0.9, Invert: true},
+			AbsThrottle: {Info: flat},
+			AbsRudder:   {Info: stick, Curve: CurveEaseIn},
+			AbsZ:        {Info: trigger, Trigger: true, Deadzone: 0.1},
+			AbsRZ:       {Info: trigger, Trigger: true, Invert: true},
+		},
+		Sticks: [][2]int{{AbsX, AbsY}},
+	}
+
+	tests := []struct {
+		name string
+		raw  map[int]int32
+		axis int
+		want float64
+	}{
+		{"axial deadzone", map[int]int32{AbsRX: 15}, AbsRX, 0},
+		{"axial", map[int]int32{AbsRX: 60}, AbsRX, 0.5},
+		{"axial negative", map[int]int32{AbsRX: -60}, AbsRX, -0.5},
+		{"saturation", map[int]int32{AbsRY: 95}, AbsRY, -1},
+		{"saturation inverted", map[int]int32{AbsRY: -50}, AbsRY, 0.5},
+		{"flat", map[int]int32{AbsThrottle: 8}, AbsThrottle, 0},
+		{"beyond flat", map[int]int32{AbsThrottle: 55}, AbsThrottle, 0.5},
+		{"clamped", map[int]int32{AbsThrottle: 400}, AbsThrottle, 1},
+		{"curve", map[int]int32{AbsRudder: -50}, AbsRudder, -0.25},
+		{"trigger deadzone", map[int]int32{AbsZ: 15}, AbsZ, 0},
+		{"trigger", map[int]int32{AbsZ: 110}, AbsZ, 0.5},
+		{"trigger inverted", map[int]int32{AbsRZ: 50}, AbsRZ, 0.75},
+		{"radial", map[int]int32{AbsX: 60, AbsY: 0}, AbsX, 0.5},
+		{"radial other axis", map[int]int32{AbsX: 0, AbsY: 60}, AbsY, -0.5},
+		{"radial deadzone", map[int]int32{AbsX: 10, AbsY: 10}, AbsX, 0},
+		{"radial diagonal", map[int]int32{AbsX: 100, AbsY: -100}, AbsX, math.Sqrt(0.5)},
+		{"radial diagonal inverted", map[int]int32{AbsX: 100, AbsY: -100}, AbsY, math.Sqrt(0.5)},
+		{"unknown axis", map[int]int32{AbsHat0X: 1}, AbsHat0X, 0},
+	}
+
+	for _, tt := range tests {
+		for axis, v := range tt.raw {
+			p.Set(axis, v)
+		}
+
+		if got := p.Value(tt.axis); math.Abs(got-tt.want) > 1e-9 {
+			t.Fatalf("%s: Want %v, have %v", tt.name, tt.want, got)
+		}
+	}
+
+	// Radial: a small diagonal deflection leaves the deadzone, even
+	// though neither axis on its own would.
+	p.Set(AbsX, 18)
+	p.Set(AbsY, 18)
+	if x, y := p.Value(AbsX), p.Value(AbsY); x <= 0 || math.Abs(x+y) > 1e-9 {
+		t.Fatalf("Radial deadzone: Want x > 0 and y == x, have (%v, %v)", x, y)
+	}
+}
+
+func TestAxisFilter(t *testing.T) {
+	stick := AbsInfo{Minimum: -100, Maximum: 100}
+
+	p := &AxisProcessor{
+		Axes: map[int]AxisConfig{
+			AbsX: {Info: stick, Deadzone: 0.2},
+			AbsY: {Info: stick, Deadzone: 0.2},
+			AbsZ: {Info: AbsInfo{Maximum: 255}, Trigger: true},
+		},
+		Sticks: [][2]int{{AbsX, AbsY}},
+		Output: AbsInfo{Minimum: -1000, Maximum: 1000},
+	}
+
+	abs := func(code int, v int32) Event {
+		return Event{Type: EvAbsolute, Code: uint16(code), Value: v}
+	}
+
+	tests := []struct {
+		in   Frame
+		want Frame
+	}{
+		{
+			Frame{key(BtnA, 1), abs(AbsX, 60), abs(AbsHat0X, 1)},
+			Frame{key(BtnA, 1), abs(AbsHat0X, 1), abs(AbsX, 500), abs(AbsY, 0)},
+		},
+		{
+			Frame{abs(AbsZ, 255)},
+			Frame{abs(AbsZ, 1000)},
+		},
+		{
+			Frame{abs(AbsX, 0), abs(AbsY, -100)},
+			Frame{abs(AbsX, 0), abs(AbsY, -1000)},
+		},
+	}
+
+	for i, tt := range tests {
+		if got := p.apply(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("Frame %d: Want %v, have %v", i, tt.want, got)
+		}
+	}
+}
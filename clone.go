@@ -0,0 +1,147 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+// CloneOptions control how CloneDevice deviates from the original.
+// The zero value yields an exact copy.
+type CloneOptions struct {
+	Name string // Replaces the device name, if not empty.
+	Phys string // Sets the physical path. This is not copied by default.
+	Id   *Id    // Replaces the device identity, if not nil.
+
+	// Add lists extra codes to enable, per event type.
+	// E.g.: {EvKeys: {KeyLeftCtrl}}
+	Add map[int][]int
+
+	// Remove lists codes to disable, per event type.
+	// Listing a type with no codes removes the type altogether.
+	Remove map[int][]int
+
+	// Absolute adds or replaces the axis information
+	// for the given absolute axes.
+	Absolute map[int]AbsInfo
+
+	// ForceFeedback handles the force feedback requests sent to the
+	// clone. If the original supports force feedback, the clone only
+	// does so when this is set. Use ForwardEffects to pass the requests
+	// on to the original.
+	ForceFeedback *FFHandler
+}
+
+// CloneConfig returns the configuration for a virtual device which
+// is identical to the given device: it has the same name, identity,
+// properties, capabilities and absolute axis ranges.
+//
+// Force feedback capabilities are not included. A virtual device
+// which advertises them, must be able to handle effect uploads.
+// See CloneOptions.ForceFeedback.
+func CloneConfig(dev *Device) VirtualConfig {
+	var cfg VirtualConfig
+	cfg.Name = dev.Name()
+	cfg.Id = dev.Id()
+	cfg.Properties = dev.Properties()
+	cfg.Capabilities = make(map[int]Bitset)
+	cfg.Absolute = make(map[int]AbsInfo)
+
+	types := dev.EventTypes()
+
+	for evtype := 1; evtype < EvCount; evtype++ {
+		if !types.Test(evtype) || evtype == EvForceFeedback {
+			continue
+		}
+
+		cfg.Capabilities[evtype] = dev.Capabilities(evtype)
+	}
+
+	axes := cfg.Capabilities[EvAbsolute]
+
+	for axis := 0; axis < AbsCount; axis++ {
+		if axes.Test(axis) {
+			cfg.Absolute[axis] = dev.AbsoluteInfo(axis)
+		}
+	}
+
+	return cfg
+}
+
+// CloneDevice creates a virtual device which looks exactly like
+// the given device to applications, save for the changes listed
+// in the options. Its repeat settings are copied as well.
+//
+// This is the basis for transparent remapping: grab the original,
+// read its events, modify them and write them to the clone.
+func CloneDevice(dev *Device, opt CloneOptions) (*VirtualDevice, error) {
+	cfg := CloneConfig(dev)
+
+	if len(opt.Name) > 0 {
+		cfg.Name = opt.Name
+	}
+
+	if len(opt.Phys) > 0 {
+		cfg.Phys = opt.Phys
+	}
+
+	if opt.Id != nil {
+		cfg.Id = *opt.Id
+	}
+
+	if opt.ForceFeedback != nil && dev.EventTypes().Test(EvForceFeedback) {
+		cfg.EffectsMax, cfg.Capabilities[EvForceFeedback] = dev.ForceFeedbackCaps()
+		cfg.ForceFeedback = opt.ForceFeedback
+	}
+
+	for evtype, codes := range opt.Add {
+		set := cfg.Capabilities[evtype]
+		if set == nil {
+			set = NewBitset(capCount(evtype))
+			cfg.Capabilities[evtype] = set
+		}
+
+		for _, code := range codes {
+			set.Set(code)
+		}
+	}
+
+	for evtype, codes := range opt.Remove {
+		if len(codes) == 0 {
+			delete(cfg.Capabilities, evtype)
+
+			if evtype == EvAbsolute {
+				cfg.Absolute = nil
+			}
+			continue
+		}
+
+		for _, code := range codes {
+			cfg.Capabilities[evtype].Unset(code)
+
+			if evtype == EvAbsolute {
+				delete(cfg.Absolute, code)
+			}
+		}
+	}
+
+	for axis, info := range opt.Absolute {
+		if cfg.Absolute == nil {
+			cfg.Absolute = make(map[int]AbsInfo)
+		}
+
+		cfg.Absolute[axis] = info
+	}
+
+	v, err := CreateVirtual(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := cfg.Capabilities[EvRepeat]; ok {
+		err = v.SetRepeatState(dev.RepeatState())
+		if err != nil {
+			v.Close()
+			return nil, err
+		}
+	}
+
+	return v, nil
+}
@@ -0,0 +1,81 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+//go:generate go run mkcodes.go
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// CodeName returns the name of the given event code, as used in this
+// package: e.g. "KeyA", "BtnLeft" or "RelX". Codes which have no name,
+// are formatted as "type:code" in hexadecimal; e.g. "0x04:0x04".
+func CodeName(evtype, code int) string {
+	var names map[int]string
+
+	switch evtype {
+	case EvKeys:
+		names = keyNames
+	case EvRelative:
+		names = relNames
+	case EvAbsolute:
+		names = absNames
+	}
+
+	if name, ok := names[code]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("0x%02x:0x%02x", evtype, code)
+}
+
+// ParseCode returns the event type and code for the given name.
+// It accepts anything returned by CodeName, as well as aliases,
+// such as "BtnSouth" for BtnA.
+func ParseCode(name string) (int, int, error) {
+	codeIndexOnce.Do(buildCodeIndex)
+
+	if c, ok := codeIndex[name]; ok {
+		return c[0], c[1], nil
+	}
+
+	if i := strings.IndexByte(name, ':'); i > 0 {
+		evtype, err1 := strconv.ParseUint(name[:i], 0, 16)
+		code, err2 := strconv.ParseUint(name[i+1:], 0, 16)
+
+		if err1 == nil && err2 == nil {
+			return int(evtype), int(code), nil
+		}
+	}
+
+	return 0, 0, fmt.Errorf("unknown event code %q", name)
+}
+
+var (
+	codeIndex     map[string][2]int
+	codeIndexOnce sync.Once
+)
+
+// buildCodeIndex builds the reverse lookup table for ParseCode.
+func buildCodeIndex() {
+	codeIndex = make(map[string][2]int)
+
+	for evtype, names := range map[int]map[int]string{
+		EvKeys:     keyNames,
+		EvRelative: relNames,
+		EvAbsolute: absNames,
+	} {
+		for code, name := range names {
+			codeIndex[name] = [2]int{evtype, code}
+		}
+	}
+
+	for name, code := range codeAliases {
+		codeIndex[name] = [2]int{EvKeys, code}
+	}
+}
@@ -0,0 +1,584 @@
+// Code generated by mkcodes.go; DO NOT EDIT.
+
+package evdev
+
+var keyNames = map[int]string{
+	KeyReserved:         "KeyReserved",
+	KeyEscape:           "KeyEscape",
+	Key1:                "Key1",
+	Key2:                "Key2",
+	Key3:                "Key3",
+	Key4:                "Key4",
+	Key5:                "Key5",
+	Key6:                "Key6",
+	Key7:                "Key7",
+	Key8:                "Key8",
+	Key9:                "Key9",
+	Key0:                "Key0",
+	KeyMinus:            "KeyMinus",
+	KeyEqual:            "KeyEqual",
+	KeyBackSpace:        "KeyBackSpace",
+	KeyTab:              "KeyTab",
+	KeyQ:                "KeyQ",
+	KeyW:                "KeyW",
+	KeyE:                "KeyE",
+	KeyR:                "KeyR",
+	KeyT:                "KeyT",
+	KeyY:                "KeyY",
+	KeyU:                "KeyU",
+	KeyI:                "KeyI",
+	KeyO:                "KeyO",
+	KeyP:                "KeyP",
+	KeyLeftBrace:        "KeyLeftBrace",
+	KeyRightBrace:       "KeyRightBrace",
+	KeyEnter:            "KeyEnter",
+	KeyLeftCtrl:         "KeyLeftCtrl",
+	KeyA:                "KeyA",
+	KeyS:                "KeyS",
+	KeyD:                "KeyD",
+	KeyF:                "KeyF",
+	KeyG:                "KeyG",
+	KeyH:                "KeyH",
+	KeyJ:                "KeyJ",
+	KeyK:                "KeyK",
+	KeyL:                "KeyL",
+	KeySemiColon:        "KeySemiColon",
+	KeyApostrophe:       "KeyApostrophe",
+	KeyGrave:            "KeyGrave",
+	KeyLeftShift:        "KeyLeftShift",
+	KeyBackSlash:        "KeyBackSlash",
+	KeyZ:                "KeyZ",
+	KeyX:                "KeyX",
+	KeyC:                "KeyC",
+	KeyV:                "KeyV",
+	KeyB:                "KeyB",
+	KeyN:                "KeyN",
+	KeyM:                "KeyM",
+	KeyComma:            "KeyComma",
+	KeyDot:              "KeyDot",
+	KeySlash:            "KeySlash",
+	KeyRightShift:       "KeyRightShift",
+	KeyKPAsterisk:       "KeyKPAsterisk",
+	KeyLeftAlt:          "KeyLeftAlt",
+	KeySpace:            "KeySpace",
+	KeyCapsLock:         "KeyCapsLock",
+	KeyF1:               "KeyF1",
+	KeyF2:               "KeyF2",
+	KeyF3:               "KeyF3",
+	KeyF4:               "KeyF4",
+	KeyF5:               "KeyF5",
+	KeyF6:               "KeyF6",
+	KeyF7:               "KeyF7",
+	KeyF8:               "KeyF8",
+	KeyF9:               "KeyF9",
+	KeyF10:              "KeyF10",
+	KeyNumLock:          "KeyNumLock",
+	KeyScrollLock:       "KeyScrollLock",
+	KeyKP7:              "KeyKP7",
+	KeyKP8:              "KeyKP8",
+	KeyKP9:              "KeyKP9",
+	KeyKPMinus:          "KeyKPMinus",
+	KeyKP4:              "KeyKP4",
+	KeyKP5:              "KeyKP5",
+	KeyKP6:              "KeyKP6",
+	KeyKPPlus:           "KeyKPPlus",
+	KeyKP1:              "KeyKP1",
+	KeyKP2:              "KeyKP2",
+	KeyKP3:              "KeyKP3",
+	KeyKP0:              "KeyKP0",
+	KeyKPDot:            "KeyKPDot",
+	KeyZenkakuhankaku:   "KeyZenkakuhankaku",
+	Key102ND:            "Key102ND",
+	KeyF11:              "KeyF11",
+	KeyF12:              "KeyF12",
+	KeyRO:               "KeyRO",
+	KeyKatakana:         "KeyKatakana",
+	KeyHiragana:         "KeyHiragana",
+	KeyHenkan:           "KeyHenkan",
+	KeyKatakanaHiragana: "KeyKatakanaHiragana",
+	KeyMuhenkan:         "KeyMuhenkan",
+	KeyKPJPComma:        "KeyKPJPComma",
+	KeyKPEnter:          "KeyKPEnter",
+	KeyRightCtrl:        "KeyRightCtrl",
+	KeyKPSlash:          "KeyKPSlash",
+	KeySysRQ:            "KeySysRQ",
+	KeyRightAlt:         "KeyRightAlt",
+	KeyLineFeed:         "KeyLineFeed",
+	KeyHome:             "KeyHome",
+	KeyUp:               "KeyUp",
+	KeyPageUp:           "KeyPageUp",
+	KeyLeft:             "KeyLeft",
+	KeyRight:            "KeyRight",
+	KeyEnd:              "KeyEnd",
+	KeyDown:             "KeyDown",
+	KeyPageDown:         "KeyPageDown",
+	KeyInsert:           "KeyInsert",
+	KeyDelete:           "KeyDelete",
+	KeyMacro:            "KeyMacro",
+	KeyMute:             "KeyMute",
+	KeyVolumeDown:       "KeyVolumeDown",
+	KeyVolumeUp:         "KeyVolumeUp",
+	KeyPower:            "KeyPower",
+	KeyKPEqual:          "KeyKPEqual",
+	KeyKPPlusMinus:      "KeyKPPlusMinus",
+	KeyPause:            "KeyPause",
+	KeyScale:            "KeyScale",
+	KeyKPComma:          "KeyKPComma",
+	KeyHangeul:          "KeyHangeul",
+	KeyHanja:            "KeyHanja",
+	KeyYen:              "KeyYen",
+	KeyLeftMeta:         "KeyLeftMeta",
+	KeyRightMeta:        "KeyRightMeta",
+	KeyCompose:          "KeyCompose",
+	KeyStop:             "KeyStop",
+	KeyAgain:            "KeyAgain",
+	KeyProps:            "KeyProps",
+	KeyUndo:             "KeyUndo",
+	KeyFront:            "KeyFront",
+	KeyCopy:             "KeyCopy",
+	KeyOpen:             "KeyOpen",
+	KeyPaste:            "KeyPaste",
+	KeyFind:             "KeyFind",
+	KeyCut:              "KeyCut",
+	KeyHelp:             "KeyHelp",
+	KeyMenu:             "KeyMenu",
+	KeyCalc:             "KeyCalc",
+	KeySetup:            "KeySetup",
+	KeySleep:            "KeySleep",
+	KeyWakeup:           "KeyWakeup",
+	KeyFile:             "KeyFile",
+	KeySendFile:         "KeySendFile",
+	KeyDeleteFile:       "KeyDeleteFile",
+	KeyXFer:             "KeyXFer",
+	KeyProg1:            "KeyProg1",
+	KeyProg2:            "KeyProg2",
+	KeyWWW:              "KeyWWW",
+	KeyMSDOS:            "KeyMSDOS",
+	KeyCoffee:           "KeyCoffee",
+	KeyDirection:        "KeyDirection",
+	KeyCycleWindows:     "KeyCycleWindows",
+	KeyMail:             "KeyMail",
+	KeyBookmarks:        "KeyBookmarks",
+	KeyComputer:         "KeyComputer",
+	KeyBack:             "KeyBack",
+	KeyForward:          "KeyForward",
+	KeyCloseCD:          "KeyCloseCD",
+	KeyEjectCD:          "KeyEjectCD",
+	KeyEjectCloseCD:     "KeyEjectCloseCD",
+	KeyNextSong:         "KeyNextSong",
+	KeyPlayPause:        "KeyPlayPause",
+	KeyPreviousSong:     "KeyPreviousSong",
+	KeyStopCD:           "KeyStopCD",
+	KeyRecord:           "KeyRecord",
+	KeyRewind:           "KeyRewind",
+	KeyPhone:            "KeyPhone",
+	KeyISO:              "KeyISO",
+	KeyConfig:           "KeyConfig",
+	KeyHomepage:         "KeyHomepage",
+	KeyRefresh:          "KeyRefresh",
+	KeyExit:             "KeyExit",
+	KeyMove:             "KeyMove",
+	KeyEdit:             "KeyEdit",
+	KeyScrollUp:         "KeyScrollUp",
+	KeyScrollDown:       "KeyScrollDown",
+	KeyKPLeftParen:      "KeyKPLeftParen",
+	KeyKPRightParen:     "KeyKPRightParen",
+	KeyNew:              "KeyNew",
+	KeyRedo:             "KeyRedo",
+	KeyF13:              "KeyF13",
+	KeyF14:              "KeyF14",
+	KeyF15:              "KeyF15",
+	KeyF16:              "KeyF16",
+	KeyF17:              "KeyF17",
+	KeyF18:              "KeyF18",
+	KeyF19:              "KeyF19",
+	KeyF20:              "KeyF20",
+	KeyF21:              "KeyF21",
+	KeyF22:              "KeyF22",
+	KeyF23:              "KeyF23",
+	KeyF24:              "KeyF24",
+	KeyPlayCD:           "KeyPlayCD",
+	KeyPauseCD:          "KeyPauseCD",
+	KeyProg3:            "KeyProg3",
+	KeyProg4:            "KeyProg4",
+	KeyDashboard:        "KeyDashboard",
+	KeySuspend:          "KeySuspend",
+	KeyClose:            "KeyClose",
+	KeyPlay:             "KeyPlay",
+	KeyFastForward:      "KeyFastForward",
+	KeyBassBoost:        "KeyBassBoost",
+	KeyPrint:            "KeyPrint",
+	KeyHP:               "KeyHP",
+	KeyCanera:           "KeyCanera",
+	KeySound:            "KeySound",
+	KeyQuestion:         "KeyQuestion",
+	KeyEmail:            "KeyEmail",
+	KeyChat:             "KeyChat",
+	KeySearch:           "KeySearch",
+	KeyConnect:          "KeyConnect",
+	KeyFinance:          "KeyFinance",
+	KeySport:            "KeySport",
+	KeyShop:             "KeyShop",
+	KeyAltErase:         "KeyAltErase",
+	KeyCancel:           "KeyCancel",
+	KeyBrightnessDown:   "KeyBrightnessDown",
+	KeyBrightnessUp:     "KeyBrightnessUp",
+	KeyMedia:            "KeyMedia",
+	KeySwitchVideoMode:  "KeySwitchVideoMode",
+	KeyKBDIllumToggle:   "KeyKBDIllumToggle",
+	KeyKBDIllumDown:     "KeyKBDIllumDown",
+	KeyKBDIllumUp:       "KeyKBDIllumUp",
+	KeySend:             "KeySend",
+	KeyReply:            "KeyReply",
+	KeyForwardMail:      "KeyForwardMail",
+	KeySave:             "KeySave",
+	KeyDocuments:        "KeyDocuments",
+	KeyBattery:          "KeyBattery",
+	KeyBluetooth:        "KeyBluetooth",
+	KeyWLAN:             "KeyWLAN",
+	KeyUWB:              "KeyUWB",
+	KeyUnknown:          "KeyUnknown",
+	KeyVideoNext:        "KeyVideoNext",
+	KeyVideoPrevious:    "KeyVideoPrevious",
+	KeyBrightnessCycle:  "KeyBrightnessCycle",
+	KeyBrightnessZero:   "KeyBrightnessZero",
+	KeyDisplayOff:       "KeyDisplayOff",
+	KeyRFKill:           "KeyRFKill",
+	KeyMicMute:          "KeyMicMute",
+	Btn0:                "Btn0",
+	Btn1:                "Btn1",
+	Btn2:                "Btn2",
+	Btn3:                "Btn3",
+	Btn4:                "Btn4",
+	Btn5:                "Btn5",
+	Btn6:                "Btn6",
+	Btn7:                "Btn7",
+	Btn8:                "Btn8",
+	Btn9:                "Btn9",
+	BtnLeft:             "BtnLeft",
+	BtnRight:            "BtnRight",
+	BtnMiddle:           "BtnMiddle",
+	BtnSide:             "BtnSide",
+	BtnExtra:            "BtnExtra",
+	BtnForward:          "BtnForward",
+	BtnBack:             "BtnBack",
+	BtnTask:             "BtnTask",
+	BtnTrigger:          "BtnTrigger",
+	BtnThumb:            "BtnThumb",
+	BtnThumb2:           "BtnThumb2",
+	BtnTop:              "BtnTop",
+	BtnTop2:             "BtnTop2",
+	BtnPinkie:           "BtnPinkie",
+	BtnBase:             "BtnBase",
+	BtnBase2:            "BtnBase2",
+	BtnBase3:            "BtnBase3",
+	BtnBase4:            "BtnBase4",
+	BtnBase5:            "BtnBase5",
+	BtnBase6:            "BtnBase6",
+	BtnDead:             "BtnDead",
+	BtnA:                "BtnA",
+	BtnB:                "BtnB",
+	BtnC:                "BtnC",
+	BtnX:                "BtnX",
+	BtnY:                "BtnY",
+	BtnZ:                "BtnZ",
+	BtnTL:               "BtnTL",
+	BtnTR:               "BtnTR",
+	BtnTL2:              "BtnTL2",
+	BtnTR2:              "BtnTR2",
+	BtnSelect:           "BtnSelect",
+	BtnStart:            "BtnStart",
+	BtnMode:             "BtnMode",
+	BtnThumbL:           "BtnThumbL",
+	BtnThumbR:           "BtnThumbR",
+	BtnToolPen:          "BtnToolPen",
+	BtnTooLRubber:       "BtnTooLRubber",
+	BtnToolBrush:        "BtnToolBrush",
+	BtnToolPencil:       "BtnToolPencil",
+	BtnToolAirbrush:     "BtnToolAirbrush",
+	BtnToolFinger:       "BtnToolFinger",
+	BtnToolMouse:        "BtnToolMouse",
+	BtnToolLens:         "BtnToolLens",
+	BtnToolQuintTap:     "BtnToolQuintTap",
+	BtnTouch:            "BtnTouch",
+	BtnStylus:           "BtnStylus",
+	BtnStylus2:          "BtnStylus2",
+	BtnToolDoubleTap:    "BtnToolDoubleTap",
+	BtnToolTrippleTap:   "BtnToolTrippleTap",
+	BtnToolQuadTap:      "BtnToolQuadTap",
+	BtnGearDown:         "BtnGearDown",
+	BtnGearUp:           "BtnGearUp",
+	KeyOk:               "KeyOk",
+	KeySelect:           "KeySelect",
+	KeyGoto:             "KeyGoto",
+	KeyClear:            "KeyClear",
+	KeyPower2:           "KeyPower2",
+	KeyOption:           "KeyOption",
+	KeyInfo:             "KeyInfo",
+	KeyTime:             "KeyTime",
+	KeyVendor:           "KeyVendor",
+	KeyArchive:          "KeyArchive",
+	KeyProgram:          "KeyProgram",
+	KeyChannel:          "KeyChannel",
+	KeyFavorites:        "KeyFavorites",
+	KeyEPG:              "KeyEPG",
+	KeyPVR:              "KeyPVR",
+	KeyMHP:              "KeyMHP",
+	KeyLanguage:         "KeyLanguage",
+	KeyTitle:            "KeyTitle",
+	KeySubtitle:         "KeySubtitle",
+	KeyAngle:            "KeyAngle",
+	KeyZoom:             "KeyZoom",
+	KeyMode:             "KeyMode",
+	KeyKeyboard:         "KeyKeyboard",
+	KeyScreen:           "KeyScreen",
+	KeyPC:               "KeyPC",
+	KeyTV:               "KeyTV",
+	KeyTV2:              "KeyTV2",
+	KeyVCR:              "KeyVCR",
+	KeyVCR2:             "KeyVCR2",
+	KeySAT:              "KeySAT",
+	KeySAT2:             "KeySAT2",
+	KeyCD:               "KeyCD",
+	KeyTape:             "KeyTape",
+	KeyRadio:            "KeyRadio",
+	KeyTuner:            "KeyTuner",
+	KeyPlayer:           "KeyPlayer",
+	KeyText:             "KeyText",
+	KeyDVD:              "KeyDVD",
+	KeyAUX:              "KeyAUX",
+	KeyMP3:              "KeyMP3",
+	KeyAudio:            "KeyAudio",
+	KeyVideo:            "KeyVideo",
+	KeyDirectory:        "KeyDirectory",
+	KeyList:             "KeyList",
+	KeyMemo:             "KeyMemo",
+	KeyCalender:         "KeyCalender",
+	KeyRed:              "KeyRed",
+	KeyGreen:            "KeyGreen",
+	KeyYellow:           "KeyYellow",
+	KeyBlue:             "KeyBlue",
+	KeyChannelUp:        "KeyChannelUp",
+	KeyChannelDown:      "KeyChannelDown",
+	KeyFirst:            "KeyFirst",
+	KeyLast:             "KeyLast",
+	KeyAB:               "KeyAB",
+	KeyNext:             "KeyNext",
+	KeyRestart:          "KeyRestart",
+	KeySlow:             "KeySlow",
+	KeyShuffle:          "KeyShuffle",
+	KeyBreak:            "KeyBreak",
+	KeyPrevious:         "KeyPrevious",
+	KeyDigits:           "KeyDigits",
+	KeyTeen:             "KeyTeen",
+	KeyTwen:             "KeyTwen",
+	KeyVideoPhone:       "KeyVideoPhone",
+	KeyGames:            "KeyGames",
+	KeyZoomIn:           "KeyZoomIn",
+	KeyZoomOut:          "KeyZoomOut",
+	KeyZoomReset:        "KeyZoomReset",
+	KeyWordProcessor:    "KeyWordProcessor",
+	KeyEditor:           "KeyEditor",
+	KeySpreadsheet:      "KeySpreadsheet",
+	KeyGraphicsEditor:   "KeyGraphicsEditor",
+	KeyPresentation:     "KeyPresentation",
+	KeyDatabase:         "KeyDatabase",
+	KeyNews:             "KeyNews",
+	KeyVoiceMail:        "KeyVoiceMail",
+	KeyAddressBook:      "KeyAddressBook",
+	KeyMessenger:        "KeyMessenger",
+	KeyDisplayToggle:    "KeyDisplayToggle",
+	KeySpellCheck:       "KeySpellCheck",
+	KeyLogoff:           "KeyLogoff",
+	KeyDollar:           "KeyDollar",
+	KeyEuro:             "KeyEuro",
+	KeyFrameBack:        "KeyFrameBack",
+	KeyContextMenu:      "KeyContextMenu",
+	KeyMediaRepeat:      "KeyMediaRepeat",
+	Key10ChannelsUp:     "Key10ChannelsUp",
+	Key10ChannelsDown:   "Key10ChannelsDown",
+	KeyImages:           "KeyImages",
+	KeyDelEOL:           "KeyDelEOL",
+	KeyDelEOS:           "KeyDelEOS",
+	KeyInsLine:          "KeyInsLine",
+	KeyDelLine:          "KeyDelLine",
+	KeyFN:               "KeyFN",
+	KeyFNEsc:            "KeyFNEsc",
+	KeyFNF1:             "KeyFNF1",
+	KeyFNF2:             "KeyFNF2",
+	KeyFNF3:             "KeyFNF3",
+	KeyFNF4:             "KeyFNF4",
+	KeyFNF5:             "KeyFNF5",
+	KeyFNF6:             "KeyFNF6",
+	KeyFNF7:             "KeyFNF7",
+	KeyFNF8:             "KeyFNF8",
+	KeyFNF9:             "KeyFNF9",
+	KeyFNF10:            "KeyFNF10",
+	KeyFNF11:            "KeyFNF11",
+	KeyFNF12:            "KeyFNF12",
+	KeyFN1:              "KeyFN1",
+	KeyFN2:              "KeyFN2",
+	KeyFND:              "KeyFND",
+	KeyFNE:              "KeyFNE",
+	KeyFNF:              "KeyFNF",
+	KeyFNS:              "KeyFNS",
+	KeyFNB:              "KeyFNB",
+	KeyBRLDot1:          "KeyBRLDot1",
+	KeyBRLDot2:          "KeyBRLDot2",
+	KeyBRLDot3:          "KeyBRLDot3",
+	KeyBRLDot4:          "KeyBRLDot4",
+	KeyBRLDot5:          "KeyBRLDot5",
+	KeyBRLDot6:          "KeyBRLDot6",
+	KeyBRLDot7:          "KeyBRLDot7",
+	KeyBRLDot8:          "KeyBRLDot8",
+	KeyBRLDot9:          "KeyBRLDot9",
+	KeyBRLDot10:         "KeyBRLDot10",
+	KeyNumeric0:         "KeyNumeric0",
+	KeyNumeric1:         "KeyNumeric1",
+	KeyNumeric2:         "KeyNumeric2",
+	KeyNumeric3:         "KeyNumeric3",
+	KeyNumeric4:         "KeyNumeric4",
+	KeyNumeric5:         "KeyNumeric5",
+	KeyNumeric6:         "KeyNumeric6",
+	KeyNumeric7:         "KeyNumeric7",
+	KeyNumeric8:         "KeyNumeric8",
+	KeyNumeric9:         "KeyNumeric9",
+	KeyNumericStar:      "KeyNumericStar",
+	KeyNumericPound:     "KeyNumericPound",
+	KeyCameraFocus:      "KeyCameraFocus",
+	KeyWPSButton:        "KeyWPSButton",
+	KeyTouchpadToggle:   "KeyTouchpadToggle",
+	KeyTouchpadOn:       "KeyTouchpadOn",
+	KeyTouchpadOff:      "KeyTouchpadOff",
+	KeyCameraZoomIn:     "KeyCameraZoomIn",
+	KeyCameraZoomOut:    "KeyCameraZoomOut",
+	KeyCameraUp:         "KeyCameraUp",
+	KeyCameraDown:       "KeyCameraDown",
+	KeyCameraLeft:       "KeyCameraLeft",
+	KeyCameraRight:      "KeyCameraRight",
+	KeyAttendantOn:      "KeyAttendantOn",
+	KeyAttendantOff:     "KeyAttendantOff",
+	KeyAttendantToggle:  "KeyAttendantToggle",
+	KeyLightsToggle:     "KeyLightsToggle",
+	BtnDpadUp:           "BtnDpadUp",
+	BtnDpadDown:         "BtnDpadDown",
+	BtnDpadLeft:         "BtnDpadLeft",
+	BtnDpadRight:        "BtnDpadRight",
+	BtnTriggerHappy1:    "BtnTriggerHappy1",
+	BtnTriggerHappy2:    "BtnTriggerHappy2",
+	BtnTriggerHappy3:    "BtnTriggerHappy3",
+	BtnTriggerHappy4:    "BtnTriggerHappy4",
+	BtnTriggerHappy5:    "BtnTriggerHappy5",
+	BtnTriggerHappy6:    "BtnTriggerHappy6",
+	BtnTriggerHappy7:    "BtnTriggerHappy7",
+	BtnTriggerHappy8:    "BtnTriggerHappy8",
+	BtnTriggerHappy9:    "BtnTriggerHappy9",
+	BtnTriggerHappy10:   "BtnTriggerHappy10",
+	BtnTriggerHappy11:   "BtnTriggerHappy11",
+	BtnTriggerHappy12:   "BtnTriggerHappy12",
+	BtnTriggerHappy13:   "BtnTriggerHappy13",
+	BtnTriggerHappy14:   "BtnTriggerHappy14",
+	BtnTriggerHappy15:   "BtnTriggerHappy15",
+	BtnTriggerHappy16:   "BtnTriggerHappy16",
+	BtnTriggerHappy17:   "BtnTriggerHappy17",
+	BtnTriggerHappy18:   "BtnTriggerHappy18",
+	BtnTriggerHappy19:   "BtnTriggerHappy19",
+	BtnTriggerHappy20:   "BtnTriggerHappy20",
+	BtnTriggerHappy21:   "BtnTriggerHappy21",
+	BtnTriggerHappy22:   "BtnTriggerHappy22",
+	BtnTriggerHappy23:   "BtnTriggerHappy23",
+	BtnTriggerHappy24:   "BtnTriggerHappy24",
+	BtnTriggerHappy25:   "BtnTriggerHappy25",
+	BtnTriggerHappy26:   "BtnTriggerHappy26",
+	BtnTriggerHappy27:   "BtnTriggerHappy27",
+	BtnTriggerHappy28:   "BtnTriggerHappy28",
+	BtnTriggerHappy29:   "BtnTriggerHappy29",
+	BtnTriggerHappy30:   "BtnTriggerHappy30",
+	BtnTriggerHappy31:   "BtnTriggerHappy31",
+	BtnTriggerHappy32:   "BtnTriggerHappy32",
+	BtnTriggerHappy33:   "BtnTriggerHappy33",
+	BtnTriggerHappy34:   "BtnTriggerHappy34",
+	BtnTriggerHappy35:   "BtnTriggerHappy35",
+	BtnTriggerHappy36:   "BtnTriggerHappy36",
+	BtnTriggerHappy37:   "BtnTriggerHappy37",
+	BtnTriggerHappy38:   "BtnTriggerHappy38",
+	BtnTriggerHappy39:   "BtnTriggerHappy39",
+	BtnTriggerHappy40:   "BtnTriggerHappy40",
+}
+
+var relNames = map[int]string{
+	RelX:           "RelX",
+	RelY:           "RelY",
+	RelZ:           "RelZ",
+	RelRX:          "RelRX",
+	RelRY:          "RelRY",
+	RelRZ:          "RelRZ",
+	RelHWheel:      "RelHWheel",
+	RelDial:        "RelDial",
+	RelWheel:       "RelWheel",
+	RelMisc:        "RelMisc",
+	RelReserved:    "RelReserved",
+	RelWheelHiRes:  "RelWheelHiRes",
+	RelHWheelHiRes: "RelHWheelHiRes",
+}
+
+var absNames = map[int]string{
+	AbsX:             "AbsX",
+	AbsY:             "AbsY",
+	AbsZ:             "AbsZ",
+	AbsRX:            "AbsRX",
+	AbsRY:            "AbsRY",
+	AbsRZ:            "AbsRZ",
+	AbsThrottle:      "AbsThrottle",
+	AbsRudder:        "AbsRudder",
+	AbsWheel:         "AbsWheel",
+	AbsGas:           "AbsGas",
+	AbsBrake:         "AbsBrake",
+	AbsHat0X:         "AbsHat0X",
+	AbsHat0Y:         "AbsHat0Y",
+	AbsHat1X:         "AbsHat1X",
+	AbsHat1Y:         "AbsHat1Y",
+	AbsHat2X:         "AbsHat2X",
+	AbsHat2Y:         "AbsHat2Y",
+	AbsHat3X:         "AbsHat3X",
+	AbsHat3Y:         "AbsHat3Y",
+	AbsPressure:      "AbsPressure",
+	AbsDistance:      "AbsDistance",
+	AbsTiltX:         "AbsTiltX",
+	AbsTiltY:         "AbsTiltY",
+	AbsToolWidth:     "AbsToolWidth",
+	AbsVolume:        "AbsVolume",
+	AbsMisc:          "AbsMisc",
+	AbsMTSlot:        "AbsMTSlot",
+	AbsMTTouchMajor:  "AbsMTTouchMajor",
+	AbsMTTouchMinor:  "AbsMTTouchMinor",
+	AbsMTWidthMajor:  "AbsMTWidthMajor",
+	AbsMTWidthMinor:  "AbsMTWidthMinor",
+	AbsMTOrientation: "AbsMTOrientation",
+	AbsMTPositionX:   "AbsMTPositionX",
+	AbsMTPositionY:   "AbsMTPositionY",
+	AbsMTToolTYPE:    "AbsMTToolTYPE",
+	AbsMTBlobId:      "AbsMTBlobId",
+	AbsMTTrackingId:  "AbsMTTrackingId",
+	AbsMTPressure:    "AbsMTPressure",
+	AbsMTDistance:    "AbsMTDistance",
+	AbsMTToolX:       "AbsMTToolX",
+	AbsMTToolY:       "AbsMTToolY",
+}
+
+var codeAliases = map[string]int{
+	"KeyHanguel":        KeyHanguel,
+	"KeyScreenlock":     KeyScreenlock,
+	"KeyMinInteresting": KeyMinInteresting,
+	"BtnMisc":           BtnMisc,
+	"BtnMouse":          BtnMouse,
+	"BtnJoystick":       BtnJoystick,
+	"BtnGamepad":        BtnGamepad,
+	"BtnSouth":          BtnSouth,
+	"BtnEast":           BtnEast,
+	"BtnNorth":          BtnNorth,
+	"BtnWest":           BtnWest,
+	"BtnDigi":           BtnDigi,
+	"BtnWheel":          BtnWheel,
+	"BtnTriggerHappy":   BtnTriggerHappy,
+}
@@ -0,0 +1,279 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultDebounceWindow is the default debounce window. Healthy key
+// switches settle well within it.
+const DefaultDebounceWindow = 5 * time.Millisecond
+
+// Debounce algorithms.
+const (
+	// DebounceEager passes a change of a key on immediately, then
+	// ignores the key for the length of the window. If the key ends
+	// up in a different state by then, that state is passed on at
+	// the end of the window. This adds no latency.
+	DebounceEager = iota
+
+	// DebounceDeferred passes a change of a key on once the key has
+	// been stable for the length of the window. This adds latency,
+	// but is immune to noise; a single spurious change never gets
+	// through.
+	DebounceDeferred
+)
+
+// DebounceStats holds the statistics of a single key, as collected by
+// a Debouncer. Keys with a high proportion of suppressed transitions
+// have worn or dirty switches.
+type DebounceStats struct {
+	Transitions int // Presses and releases reported by the device.
+	Suppressed  int // Transitions which were not passed on.
+}
+
+// Debouncer is a Filter which suppresses the chatter of worn key
+// switches: presses and releases which follow one another more
+// quickly than a human can type. Key repeats are passed on only
+// while the key is pressed, and events other than key events pass
+// through unchanged.
+//
+// Windows are measured with the event timestamps. The configuration
+// must not be changed once processing has started.
+type Debouncer struct {
+	// Algorithm is the debounce algorithm: DebounceEager or DebounceDeferred.
+	Algorithm int
+
+	// Window is the time in which a key must settle.
+	// It defaults to DefaultDebounceWindow.
+	Window time.Duration
+
+	// Keys overrides the window for individual keys.
+	Keys map[int]time.Duration
+
+	mu    sync.Mutex
+	keys  map[int]*debounceKey
+	stats map[int]DebounceStats
+}
+
+// debounceKey holds the state of a single key.
+type debounceKey struct {
+	raw      int32         // State reported by the device.
+	reported int32         // State passed on.
+	since    time.Duration // Eager: start of the lockout. Deferred: time raw last changed.
+	locked   bool          // Eager: within the lockout.
+	pending  int           // Transitions since, not yet accounted for.
+}
+
+// NewDebouncer creates a debouncer with the given algorithm and window.
+func NewDebouncer(algorithm int, window time.Duration) *Debouncer {
+	return &Debouncer{Algorithm: algorithm, Window: window}
+}
+
+// Stats returns the statistics per key code, collected so far.
+// It is safe to call while the debouncer is running.
+func (d *Debouncer) Stats() map[int]DebounceStats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	out := make(map[int]DebounceStats, len(d.stats))
+	for code, s := range d.stats {
+		out[code] = s
+	}
+
+	return out
+}
+
+// Feed passes a single event to the debouncer and returns the events
+// to pass on: the settled changes which were due before it, followed
+// by the event itself, if it passes.
+func (d *Debouncer) Feed(e Event) []Event {
+	t := eventTime(e)
+	out := d.Tick(t)
+
+	if e.Type != EvKeys {
+		return append(out, e)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	code := int(e.Code)
+	k := d.key(code, e.Value)
+
+	if e.Value > 1 {
+		if k.reported == 1 {
+			out = append(out, e)
+		}
+		return out
+	}
+
+	if e.Value == k.raw {
+		return out
+	}
+
+	k.raw = e.Value
+	d.count(code, 1, 0)
+
+	switch {
+	case d.Algorithm == DebounceDeferred:
+		k.since = t
+		k.pending++
+
+	case k.locked:
+		k.pending++
+
+	case k.raw != k.reported:
+		k.reported = k.raw
+		k.since = t
+		k.locked = true
+		out = append(out, e)
+	}
+
+	return out
+}
+
+// Tick tells the debouncer the given time has come and returns the
+// changes which have settled by then. The time is in the same clock
+// as the event timestamps. See Deadline.
+func (d *Debouncer) Tick(now time.Duration) []Event {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var out []Event
+
+	for _, code := range d.due(now) {
+		k := d.keys[code]
+		at := k.since + d.window(code)
+		changed := k.raw != k.reported
+
+		suppressed := k.pending
+		if changed {
+			suppressed--
+
+			e := timeEvent(at)
+			e.Type = EvKeys
+			e.Code = uint16(code)
+			e.Value = k.raw
+			out = append(out, e)
+
+			k.reported = k.raw
+		}
+
+		d.count(code, 0, suppressed)
+		k.pending = 0
+
+		// An eager change starts a new lockout.
+		k.locked = changed && d.Algorithm == DebounceEager
+		k.since = at
+	}
+
+	return out
+}
+
+// Deadline returns the time at which the next window ends, if any.
+// Tick should be called at that time.
+func (d *Debouncer) Deadline() (time.Duration, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	next := time.Duration(math.MaxInt64)
+
+	for code, k := range d.keys {
+		if d.waiting(k) {
+			if t := k.since + d.window(code); t < next {
+				next = t
+			}
+		}
+	}
+
+	return next, next != math.MaxInt64
+}
+
+// Run implements Filter. The changes which are still waiting for
+// their window to end when in is closed, are passed on.
+func (d *Debouncer) Run(in <-chan Frame, out chan<- Frame) {
+	runTimed(d, in, out, func(time.Duration) []Event {
+		return d.Tick(math.MaxInt64)
+	})
+}
+
+// due returns the keys whose window has ended by the given time,
+// ordered by the time it did.
+func (d *Debouncer) due(now time.Duration) []int {
+	var list []int
+
+	for code, k := range d.keys {
+		if d.waiting(k) && k.since+d.window(code) <= now {
+			list = append(list, code)
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return d.before(list[i], list[j])
+	})
+
+	return list
+}
+
+// before returns true if the window of key a ends before that of b.
+func (d *Debouncer) before(a, b int) bool {
+	ta := d.keys[a].since + d.window(a)
+	tb := d.keys[b].since + d.window(b)
+	return ta < tb || ta == tb && a < b
+}
+
+// waiting returns true if the key is waiting for its window to end.
+func (d *Debouncer) waiting(k *debounceKey) bool {
+	if d.Algorithm == DebounceDeferred {
+		return k.pending > 0
+	}
+	return k.locked
+}
+
+// key returns the state of the given key. A key seen for the first
+// time is assumed to be in the opposite state of value; so a release
+// of a key pressed before we started, is passed on.
+func (d *Debouncer) key(code int, value int32) *debounceKey {
+	if d.keys == nil {
+		d.keys = make(map[int]*debounceKey)
+		d.stats = make(map[int]DebounceStats)
+	}
+
+	k := d.keys[code]
+	if k == nil {
+		k = new(debounceKey)
+		if value == 0 {
+			k.raw, k.reported = 1, 1
+		}
+		d.keys[code] = k
+	}
+
+	return k
+}
+
+// count adds to the statistics of the given key.
+func (d *Debouncer) count(code, transitions, suppressed int) {
+	s := d.stats[code]
+	s.Transitions += transitions
+	s.Suppressed += suppressed
+	d.stats[code] = s
+}
+
+// window returns the debounce window of the given key.
+func (d *Debouncer) window(code int) time.Duration {
+	if w, ok := d.Keys[code]; ok {
+		return w
+	}
+
+	if d.Window > 0 {
+		return d.Window
+	}
+
+	return DefaultDebounceWindow
+}
@@ -0,0 +1,114 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDebouncer(t *testing.T) {
+	tests := []struct {
+		name      string
+		algorithm int
+		events    []Event
+		tick      int // Milliseconds; 0 means no tick.
+		want      string
+		stats     map[int]DebounceStats
+	}{
+		{
+			name:      "eager chatter",
+			algorithm: DebounceEager,
+			events:    []Event{eventAt(EvKeys, KeyA, 0, 1), eventAt(EvKeys, KeyA, 1, 0), eventAt(EvKeys, KeyA, 2, 1), eventAt(EvKeys, KeyA, 50, 0)},
+			want:      "KeyA 1; KeyA 0",
+			stats:     map[int]DebounceStats{KeyA: {Transitions: 4, Suppressed: 2}},
+		},
+		{
+			name:      "eager settles late",
+			algorithm: DebounceEager,
+			events:    []Event{eventAt(EvKeys, KeyA, 0, 1), eventAt(EvKeys, KeyA, 3, 0), eventAt(EvKeys, KeyA, 7, 1)},
+			tick:      20,
+			want:      "KeyA 1; KeyA 0; KeyA 1",
+			stats:     map[int]DebounceStats{KeyA: {Transitions: 3}},
+		},
+		{
+			name:      "deferred chatter",
+			algorithm: DebounceDeferred,
+			events:    []Event{eventAt(EvKeys, KeyA, 0, 1), eventAt(EvKeys, KeyA, 1, 0), eventAt(EvKeys, KeyA, 2, 1), eventAt(EvKeys, KeyA, 50, 0)},
+			tick:      60,
+			want:      "KeyA 1; KeyA 0",
+			stats:     map[int]DebounceStats{KeyA: {Transitions: 4, Suppressed: 2}},
+		},
+		{
+			name:      "deferred spike",
+			algorithm: DebounceDeferred,
+			events:    []Event{eventAt(EvKeys, KeyA, 0, 1), eventAt(EvKeys, KeyA, 1, 0)},
+			tick:      20,
+			stats:     map[int]DebounceStats{KeyA: {Transitions: 2, Suppressed: 2}},
+		},
+		{
+			name:      "per key window",
+			algorithm: DebounceEager,
+			events:    []Event{eventAt(EvKeys, KeyB, 0, 1), eventAt(EvKeys, KeyB, 10, 0), eventAt(EvKeys, KeyB, 40, 1)},
+			tick:      100,
+			want:      "KeyB 1",
+			stats:     map[int]DebounceStats{KeyB: {Transitions: 3, Suppressed: 2}},
+		},
+		{
+			name:      "repeats",
+			algorithm: DebounceDeferred,
+			events:    []Event{eventAt(EvKeys, KeyA, 0, 1), eventAt(EvKeys, KeyA, 3, 2), eventAt(EvKeys, KeyA, 10, 2)},
+			want:      "KeyA 1; KeyA 2@10",
+			stats:     map[int]DebounceStats{KeyA: {Transitions: 1}},
+		},
+		{
+			name:      "pressed before",
+			algorithm: DebounceEager,
+			events:    []Event{eventAt(EvKeys, KeyA, 0, 0)},
+			want:      "KeyA 0",
+			stats:     map[int]DebounceStats{KeyA: {Transitions: 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		d := NewDebouncer(tt.algorithm, 5*time.Millisecond)
+		d.Keys = map[int]time.Duration{KeyB: 50 * time.Millisecond}
+
+		var got []Event
+		for _, e := range tt.events {
+			got = append(got, d.Feed(e)...)
+		}
+
+		if tt.tick > 0 {
+			got = append(got, d.Tick(time.Duration(tt.tick)*time.Millisecond)...)
+		}
+
+		if have := eventTrace(got); have != tt.want {
+			t.Fatalf("%s:\nWant %s\nhave %s", tt.name, tt.want, have)
+		}
+
+		if stats := d.Stats(); !reflect.DeepEqual(stats, tt.stats) {
+			t.Fatalf("%s: Want stats %v, have %v", tt.name, tt.stats, stats)
+		}
+	}
+
+	// The release at 100ms is still in its window when the source
+	// runs out; Run must pass it on regardless.
+	src := SliceSource{{eventAt(EvKeys, KeyA, 0, 1)}, {eventAt(EvKeys, KeyA, 100, 0)}}
+	var sink testSink
+
+	if err := NewPipeline(&src, &sink, NewDebouncer(DebounceDeferred, 5*time.Millisecond)).Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []Event
+	for _, f := range sink.frames {
+		got = append(got, f...)
+	}
+
+	if want, have := "KeyA 1; KeyA 0", eventTrace(got); have != want {
+		t.Fatalf("Run:\nWant %s\nhave %s", want, have)
+	}
+}
@@ -4,45 +4,334 @@
 package evdev
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"sync"
+	"syscall"
+	"time"
 	"unsafe"
 )
 
 const eventBufferSize = 64
 
+// ErrNotEvdev is returned when a file does not refer to an evdev node.
+var ErrNotEvdev = errors.New("evdev: not an evdev device")
+
+// Clock identifiers for Options.Clock and Device.SetClock.
+// They select the clock used to timestamp events.
+const (
+	ClockRealtime  = 0 // Wall clock time. This is the kernel default.
+	ClockMonotonic = 1 // Monotonic time; unaffected by changes to the wall clock.
+	ClockBoottime  = 7 // Monotonic time, including time spent in suspend.
+)
+
+// Options control how a device is opened by OpenWith.
+// The zero value yields the same behaviour as Open.
+type Options struct {
+	// ReadOnly opens the node without write access. Such a device can
+	// not send events to the hardware (e.g.: LED changes or force
+	// feedback playback) and its Outbox channel is nil.
+	//
+	// When ReadOnly is not set and write access is denied, the node is
+	// opened read-only regardless. Device.ReadOnly reports which of
+	// the two happened.
+	ReadOnly bool
+
+	// NonBlocking opens the node in non-blocking mode. Device.ReadEvents
+	// then returns immediately when no events are pending, instead of
+	// waiting for them.
+	//
+	// This only applies in combination with NoGoroutines, as the
+	// reader goroutine relies on blocking reads.
+	NonBlocking bool
+
+	// InboxSize is the capacity of the Inbox channel.
+	// It defaults to 64 events.
+	InboxSize int
+
+	// Grab obtains exclusive access to the device as soon as it is
+	// opened. See Device.Grab. Opening fails if the grab does.
+	Grab bool
+
+	// Clock selects the clock used to timestamp events.
+	// This is one of the ClockXXX constants. See Device.SetClock.
+	Clock int
+
+	// EventMask, when not nil, limits the event types delivered to
+	// us to those set in the mask. E.g.: a mask holding only EvKeys and
+	// EvSync skips all axis motion of a gaming keyboard with a
+	// built-in joystick. The filtering is done by the kernel and
+	// requires Linux 4.4 or newer. See Device.SetEventMask.
+	EventMask Bitset
+
+	// NoGoroutines opens the device without starting the goroutines
+	// which feed the Inbox and drain the Outbox. Both channels are nil.
+	// Events are exchanged directly through Device.ReadEvents and
+	// Device.WriteEvents instead.
+	NoGoroutines bool
+}
+
 // Device represents a single device node.
 type Device struct {
-	fd     *os.File
-	Inbox  chan Event // Channel exposing incoming events.
-	Outbox chan Event // Channel for outgoing events.
+	fd       *os.File
+	readOnly bool
+	nonBlock bool
+	once     sync.Once  // Guards Close.
+	Inbox    chan Event // Channel exposing incoming events.
+	Outbox   chan Event // Channel for outgoing events.
 }
 
 // Open opens a new device for the given node name.
 // This can be anything listed in /dev/input/event[x].
-func Open(node string) (dev *Device, err error) {
-	dev = new(Device)
-	dev.fd, err = os.OpenFile(node, os.O_RDWR, 0)
-	dev.Inbox = make(chan Event, eventBufferSize)
-	dev.Outbox = make(chan Event, 1)
-
-	go dev.pollIn()
-	go dev.pollOut()
-	return
+//
+// This is the same as calling OpenWith with the default Options.
+func Open(node string) (*Device, error) {
+	return OpenWith(node, Options{})
 }
 
-// Close closes the underlying device node.
-func (d *Device) Close() (err error) {
-	d.Release()
+// OpenWith opens a new device for the given node name,
+// using the given options.
+func OpenWith(node string, opt Options) (*Device, error) {
+	flag := os.O_RDWR
+	if opt.ReadOnly {
+		flag = os.O_RDONLY
+	}
+
+	fd, err := os.OpenFile(node, flag, 0)
+	if err != nil && flag == os.O_RDWR && os.IsPermission(err) {
+		flag = os.O_RDONLY
+		fd, err = os.OpenFile(node, flag, 0)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	dev := new(Device)
+	dev.fd = fd
+	dev.readOnly = flag == os.O_RDONLY
+
+	err = dev.setup(opt)
+	if err != nil {
+		fd.Close()
+		return nil, err
+	}
+
+	return dev, nil
+}
+
+// NewFromFile creates a device from an already opened device node.
+// E.g.: one obtained from logind's TakeDevice, or received from a
+// privileged process through ReceiveFile.
+//
+// The device takes ownership of the file and closes it in Device.Close.
+// This is the same as calling NewFromFileWith with the default Options.
+func NewFromFile(fd *os.File) (*Device, error) {
+	return NewFromFileWith(fd, Options{})
+}
+
+// NewFromFileWith creates a device from an already opened device node,
+// using the given options. Options.ReadOnly is ignored; the access mode
+// is whatever the file was opened with.
+//
+// The file is not closed if this fails.
+func NewFromFileWith(fd *os.File, opt Options) (*Device, error) {
+	if fd == nil {
+		return nil, os.ErrInvalid
+	}
+
+	dev := new(Device)
+	dev.fd = fd
+
+	if _, _, _, ok := dev.version(); !ok {
+		return nil, ErrNotEvdev
+	}
+
+	var flags uintptr
+	err := control(fd, func(sysfd uintptr) error {
+		var errno syscall.Errno
+		flags, _, errno = syscall.Syscall(syscall.SYS_FCNTL, sysfd, syscall.F_GETFL, 0)
+		if errno != 0 {
+			return errno
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	dev.readOnly = flags&syscall.O_ACCMODE == syscall.O_RDONLY
+
+	err = dev.setup(opt)
+	if err != nil {
+		return nil, err
+	}
+
+	return dev, nil
+}
+
+// NewFromFd creates a device from an already opened file descriptor.
+// The device takes ownership of the descriptor: it is replaced by a
+// duplicate in non-blocking mode, which is closed in Device.Close.
+// The descriptor is left alone if this fails.
+func NewFromFd(fd int) (*Device, error) {
+	var version uint32
+	if ioctl(uintptr(fd), _EVIOCGVERSION, unsafe.Pointer(&version)) != nil {
+		return nil, ErrNotEvdev
+	}
+
+	flags, _, errno := syscall.Syscall(syscall.SYS_FCNTL, uintptr(fd), syscall.F_GETFL, 0)
+	if errno != 0 {
+		return nil, errno
+	}
+
+	// Work on a duplicate; the finalizer of a file we drop
+	// would otherwise close the caller's descriptor.
+	dup, _, errno := syscall.Syscall(syscall.SYS_FCNTL, uintptr(fd), syscall.F_DUPFD_CLOEXEC, 0)
+	if errno != 0 {
+		return nil, errno
+	}
+
+	// This lets the runtime poller handle reads,
+	// so Device.Close can interrupt them.
+	err := syscall.SetNonblock(int(dup), true)
+	if err != nil {
+		syscall.Close(int(dup))
+		return nil, err
+	}
+
+	f := os.NewFile(dup, fmt.Sprintf("fd:%d", fd))
+
+	dev, err := NewFromFile(f)
+	if err != nil {
+		f.Close()
+		syscall.SetNonblock(fd, flags&syscall.O_NONBLOCK != 0)
+		return nil, err
+	}
+
+	syscall.Close(fd)
+	return dev, nil
+}
+
+// setup applies the given options to a freshly opened device.
+func (d *Device) setup(opt Options) error {
+	if opt.NoGoroutines && opt.NonBlocking {
+		err := control(d.fd, func(sysfd uintptr) error {
+			return syscall.SetNonblock(int(sysfd), true)
+		})
+
+		if err != nil {
+			return err
+		}
+
+		d.nonBlock = true
+	}
+
+	if opt.Clock != ClockRealtime && !d.SetClock(opt.Clock) {
+		return fmt.Errorf("evdev: unsupported clock id %d", opt.Clock)
+	}
+
+	if opt.EventMask != nil && !d.SetEventMask(EvSync, opt.EventMask) {
+		return errors.New("evdev: event masks are not supported")
+	}
+
+	if opt.Grab {
+		err := d.ioctl(_EVIOCGRAB, 1)
+		if err != nil {
+			return err
+		}
+	}
+
+	if opt.NoGoroutines {
+		return nil
+	}
+
+	size := opt.InboxSize
+	if size <= 0 {
+		size = eventBufferSize
+	}
+
+	d.Inbox = make(chan Event, size)
+	go d.pollIn()
 
-	if d.fd != nil {
-		err = d.fd.Close()
-		d.fd = nil
+	if !d.readOnly {
+		d.Outbox = make(chan Event, 1)
+		go d.pollOut()
 	}
 
+	return nil
+}
+
+// ReadOnly returns true if the device was opened without write access.
+func (d *Device) ReadOnly() bool {
+	return d.readOnly
+}
+
+// SetClock selects the clock used to timestamp events.
+// This is one of the ClockXXX constants.
+//
+// This returns false if the operation failed.
+func (d *Device) SetClock(id int) bool {
+	clk := int32(id)
+	return d.ioctl(_EVIOCSCLOCKID, unsafe.Pointer(&clk)) == nil
+}
+
+// SetEventMask limits the codes of the given event type which the
+// kernel delivers to us. Only the codes set in the mask are delivered.
+// With EvSync as the event type, the mask instead selects the
+// event types to deliver; SynReport and friends are always delivered.
+//
+// This returns false if the operation failed. Masks are supported
+// since Linux 4.4.
+func (d *Device) SetEventMask(evtype int, codes Bitset) bool {
+	var mask inputMask
+	buf := codes.Bytes()
+
+	mask.Type = uint32(evtype)
+	mask.CodesSize = uint32(len(buf))
+
+	if len(buf) > 0 {
+		mask.CodesPtr = uint64(uintptr(unsafe.Pointer(&buf[0])))
+	}
+
+	return d.ioctl(_EVIOCSMASK, unsafe.Pointer(&mask)) == nil
+}
+
+// inputMask mirrors struct input_mask.
+type inputMask struct {
+	Type      uint32
+	CodesSize uint32
+	CodesPtr  uint64
+}
+
+// ioctl performs an ioctl on the device node.
+func (d *Device) ioctl(name uintptr, data interface{}) error {
+	return fileIoctl(d.fd, name, data)
+}
+
+// Close closes the underlying device node. A read which is waiting
+// for events, returns with an error wrapping os.ErrClosed.
+func (d *Device) Close() (err error) {
+	d.once.Do(func() {
+		if d.fd != nil {
+			d.Release()
+			err = d.fd.Close()
+		}
+	})
+
 	return
 }
 
+// interrupt makes a read which is waiting for events, and every later
+// one, fail with an error wrapping os.ErrDeadlineExceeded. Unlike Close,
+// it leaves the device open for the goroutine which is reading it.
+func (d *Device) interrupt() error {
+	return d.fd.SetReadDeadline(time.Unix(1, 0))
+}
+
 // Grab attempts to gain exclusive access to this device.
 // This means that we are the only ones receiving events from
 // the device; other processes will not.
@@ -56,12 +345,12 @@ func (d *Device) Close() (err error) {
 // events, we may lock ourselves out of the system
 // and a hard reset is required to restore it.
 func (d *Device) Grab() bool {
-	return ioctl(d.fd.Fd(), _EVIOCGRAB, 1) == nil
+	return d.ioctl(_EVIOCGRAB, 1) == nil
 }
 
 // Release releases a lock, previously obtained through `Device.Grab`.
 func (d *Device) Release() bool {
-	return ioctl(d.fd.Fd(), _EVIOCGRAB, 0) == nil
+	return d.ioctl(_EVIOCGRAB, 0) == nil
 }
 
 // Test takes a bitset and a list of constants
@@ -81,6 +370,11 @@ func (d *Device) Release() bool {
 //
 //     if dev.Test(dev.RelativeAxes(), RelX, RelY, RelZ) {
 func (d *Device) Test(set Bitset, values ...int) bool {
+	return testBits(set, values...)
+}
+
+// testBits returns true only if the bitset defines all the supplied values.
+func testBits(set Bitset, values ...int) bool {
 	var count int
 
 	for i := range values {
@@ -98,8 +392,8 @@ func (d *Device) Test(set Bitset, values ...int) bool {
 // Name returns the name of the device.
 func (d *Device) Name() string {
 	var str [256]byte
-	ioctl(d.fd.Fd(), _EVIOCGNAME(256), unsafe.Pointer(&str[0]))
-	return string(str[:])
+	d.ioctl(_EVIOCGNAME(256), unsafe.Pointer(&str[0]))
+	return cstring(str[:])
 }
 
 // Path returns the physical path of the device.
@@ -125,60 +419,165 @@ func (d *Device) Name() string {
 // the multimedia function keys on a second interface.
 func (d *Device) Path() string {
 	var str [256]byte
-	ioctl(d.fd.Fd(), _EVIOCGPHYS(len(str)), unsafe.Pointer(&str[0]))
-	return string(str[:])
+	d.ioctl(_EVIOCGPHYS(len(str)), unsafe.Pointer(&str[0]))
+	return cstring(str[:])
 }
 
 // Serial returns the unique serial code for the device.
 // Most devices do not have this and will return an empty string.
 func (d *Device) Serial() string {
 	var str [256]byte
-	ioctl(d.fd.Fd(), _EVIOCGUNIQ(len(str)), unsafe.Pointer(&str[0]))
-	return string(str[:])
+	d.ioctl(_EVIOCGUNIQ(len(str)), unsafe.Pointer(&str[0]))
+	return cstring(str[:])
 }
 
 // Version returns version information for the device driver.
 // These being major, minor and revision numbers.
 func (d *Device) Version() (int, int, int) {
+	major, minor, revision, _ := d.version()
+	return major, minor, revision
+}
+
+// version returns version information for the device driver.
+// It returns false if the node is not an evdev device.
+func (d *Device) version() (int, int, int, bool) {
 	var version uint32
-	err := ioctl(d.fd.Fd(), _EVIOCGVERSION, unsafe.Pointer(&version))
+	err := d.ioctl(_EVIOCGVERSION, unsafe.Pointer(&version))
 	if err != nil {
-		return 0, 0, 0
+		return 0, 0, 0, false
 	}
 
 	return int(version>>16) & 0xffff,
 		int(version>>8) & 0xff,
-		int(version) & 0xff
+		int(version) & 0xff, true
 }
 
 // Id returns the device identity.
 func (d *Device) Id() Id {
 	var id Id
-	ioctl(d.fd.Fd(), _EVIOCGID, unsafe.Pointer(&id))
+	d.ioctl(_EVIOCGID, unsafe.Pointer(&id))
 	return id
 }
 
+// cstring returns the contents of the given buffer up to,
+// but not including, the first NUL byte.
+func cstring(buf []byte) string {
+	for i, b := range buf {
+		if b == 0 {
+			return string(buf[:i])
+		}
+	}
+
+	return string(buf)
+}
+
+// ReadEvents reads pending events from the device into the given
+// buffer and returns the number of events read. This blocks until at
+// least one event is available, unless the device was opened with
+// Options.NonBlocking; in which case it returns 0 if there are none.
+//
+// This is meant for devices opened with Options.NoGoroutines.
+// Otherwise the reader goroutine is competing for the same events.
+func (d *Device) ReadEvents(list []Event) (int, error) {
+	if len(list) == 0 {
+		return 0, nil
+	}
+
+	size := int(unsafe.Sizeof(list[0]))
+	buf := (*(*[1<<31 - 1]byte)(unsafe.Pointer(&list[0])))[:len(list)*size]
+
+	if !d.nonBlock {
+		n, err := d.fd.Read(buf)
+		return n / size, err
+	}
+
+	conn, err := d.fd.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+
+	// Read once; the runtime poller would otherwise wait for the
+	// device to become readable, which is what we want to avoid.
+	var n int
+	var rerr error
+
+	err = conn.Read(func(sysfd uintptr) bool {
+		n, rerr = syscall.Read(int(sysfd), buf)
+		return true
+	})
+
+	if err == nil {
+		err = rerr
+	}
+
+	if err == syscall.EAGAIN {
+		return 0, nil
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	return n / size, nil
+}
+
+// WriteEvents sends the given events to the device.
+//
+// This is meant for devices opened with Options.NoGoroutines.
+// Otherwise, use the Outbox channel.
+func (d *Device) WriteEvents(list ...Event) error {
+	return writeEvents(d.fd, list)
+}
+
+// writeEvents writes the given events to the given file
+// in one go, using the kernel's struct input_event layout.
+func writeEvents(fd *os.File, list []Event) error {
+	if len(list) == 0 {
+		return nil
+	}
+
+	size := int(unsafe.Sizeof(list[0]))
+	buf := (*(*[1<<31 - 1]byte)(unsafe.Pointer(&list[0])))[:len(list)*size]
+
+	n, err := fd.Write(buf)
+	if err != nil {
+		return err
+	}
+
+	if n < len(buf) {
+		return io.ErrShortWrite
+	}
+
+	return nil
+}
+
+// send queues the given event in the Outbox, or writes it
+// directly if there is no goroutine to pick it up.
+func (d *Device) send(e Event) {
+	if d.Outbox != nil {
+		d.Outbox <- e
+		return
+	}
+
+	d.WriteEvents(e)
+}
+
 // pollIn polls the device for incoming events.
 // We can receive many events with a single read.
 // This is why the outgoing event channel has a large buffer.
 func (d *Device) pollIn() {
 	defer close(d.Inbox)
 
-	var e Event
-
-	size := int(unsafe.Sizeof(e))
-	buf := make([]byte, size*eventBufferSize)
+	buf := make([]Event, eventBufferSize)
 
 	for {
-		n, err := d.fd.Read(buf)
+		n, err := d.ReadEvents(buf)
 		if err != nil {
 			return
 		}
 
-		evt := (*(*[1<<27 - 1]Event)(unsafe.Pointer(&buf[0])))[:n/size]
-
-		for n = range evt {
-			d.Inbox <- evt[n]
+		for _, e := range buf[:n] {
+			d.Inbox <- e
 		}
 	}
 }
@@ -188,19 +587,16 @@ func (d *Device) pollIn() {
 func (d *Device) pollOut() {
 	defer close(d.Outbox)
 
-	var e Event
-	size := int(unsafe.Sizeof(e))
-
 	for msg := range d.Outbox {
-		buf := (*(*[1<<27 - 1]byte)(unsafe.Pointer(&msg)))[:size]
+		err := d.WriteEvents(msg)
 
-		n, err := d.fd.Write(buf)
-		if err != nil {
-			return
+		if err == io.ErrShortWrite {
+			fmt.Fprintf(os.Stderr, "poll outbox: short write\n")
+			continue
 		}
 
-		if n < size {
-			fmt.Fprintf(os.Stderr, "poll outbox: short write\n")
+		if err != nil {
+			return
 		}
 	}
 }
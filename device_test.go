@@ -0,0 +1,200 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+func TestNonBlocking(t *testing.T) {
+	dev := fifoDevice(t, Options{NoGoroutines: true, NonBlocking: true})
+	defer dev.Close()
+
+	// Ioctls must not put the node back into blocking mode.
+	// A fifo is no evdev node, so this one fails; which is fine.
+	dev.Name()
+
+	buf := make([]Event, 4)
+
+	n, err := readEvents(t, dev, buf)
+	if n != 0 || err != nil {
+		t.Fatalf("Empty: Want 0, <nil>, have %d, %v", n, err)
+	}
+
+	want := Event{Type: EvKeys, Code: KeyA, Value: 1}
+	if err = dev.WriteEvents(want); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err = readEvents(t, dev, buf)
+	if n != 1 || err != nil {
+		t.Fatalf("Pending: Want 1, <nil>, have %d, %v", n, err)
+	}
+
+	if buf[0] != want {
+		t.Fatalf("Pending: Want %v, have %v", want, buf[0])
+	}
+}
+
+func TestReadOnlyFallback(t *testing.T) {
+	// Sysfs denies write access to read-only attributes, even to root.
+	const node = "/sys/devices/system/cpu/online"
+
+	fd, err := os.OpenFile(node, os.O_RDWR, 0)
+	if err == nil {
+		fd.Close()
+	}
+
+	if !os.IsPermission(err) {
+		t.Skipf("%s: no read-only file at hand", node)
+	}
+
+	dev, err := OpenWith(node, Options{NoGoroutines: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer dev.Close()
+
+	if !dev.ReadOnly() {
+		t.Fatalf("ReadOnly: Want true, have false")
+	}
+
+	// Asking for read-only access in the first place.
+	dev, err = OpenWith(node, Options{ReadOnly: true, NoGoroutines: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer dev.Close()
+
+	if !dev.ReadOnly() {
+		t.Fatalf("ReadOnly option: Want true, have false")
+	}
+}
+
+func TestCloseInterruptsRead(t *testing.T) {
+	dev, w := pipeDevice(t)
+	defer w.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := dev.ReadEvents(make([]Event, 4))
+		done <- err
+	}()
+
+	// Give the read a moment to block.
+	time.Sleep(10 * time.Millisecond)
+
+	if err := dev.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, os.ErrClosed) {
+			t.Fatalf("ReadEvents: Want %v, have %v", os.ErrClosed, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("ReadEvents: not interrupted by Close")
+	}
+
+	if err := dev.Close(); err != nil {
+		t.Fatalf("Second Close: Want <nil>, have %v", err)
+	}
+}
+
+// pipeDevice returns a device which reads the events written to w.
+// It has no reader goroutine.
+func pipeDevice(t *testing.T) (dev *Device, w *os.File) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &Device{fd: r}, w
+}
+
+// pipeVirtual returns a virtual device whose events can be read from r.
+func pipeVirtual(t *testing.T) (v *VirtualDevice, r *os.File) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &VirtualDevice{fd: w}, r
+}
+
+// readPipe reads the given number of events from r,
+// failing the test if they do not arrive within a second.
+func readPipe(t *testing.T, r *os.File, count int) []Event {
+	list := make([]Event, count)
+	size := int(unsafe.Sizeof(list[0]))
+	raw := unsafe.Slice((*byte)(unsafe.Pointer(&list[0])), len(list)*size)
+
+	r.SetReadDeadline(time.Now().Add(time.Second))
+
+	for n := 0; n < len(raw); {
+		m, err := r.Read(raw[n:])
+		if err != nil {
+			t.Fatalf("Read: %v", err)
+		}
+		n += m
+	}
+
+	return list
+}
+
+// fifoDevice opens a named pipe as a device. Events written to the
+// device can be read back from it.
+func fifoDevice(t *testing.T, opt Options) *Device {
+	dir, err := ioutil.TempDir("", "evdev")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	node := filepath.Join(dir, "event0")
+	if err = syscall.Mkfifo(node, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	dev, err := OpenWith(node, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dev
+}
+
+// readEvents calls dev.ReadEvents, failing the test if it
+// does not return within a second.
+func readEvents(t *testing.T, dev *Device, buf []Event) (int, error) {
+	type result struct {
+		n   int
+		err error
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		n, err := dev.ReadEvents(buf)
+		done <- result{n, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.n, r.err
+	case <-time.After(time.Second):
+		t.Fatalf("ReadEvents: blocked")
+		return 0, nil
+	}
+}
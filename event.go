@@ -44,10 +44,53 @@ const (
 func (d *Device) EventTypes() Bitset {
 	bs := NewBitset(EvMax)
 	buf := bs.Bytes()
-	ioctl(d.fd.Fd(), _EVIOCGBIT(0, EvMax), unsafe.Pointer(&buf[0]))
+	d.ioctl(_EVIOCGBIT(0, EvMax), unsafe.Pointer(&buf[0]))
 	return bs
 }
 
+// Capabilities returns a bitset indicating which codes of the given
+// event type are supported by the device. E.g.: Capabilities(EvKeys)
+// yields the set of keys and buttons the device can emit.
+// Capabilities(EvSync) is the same as EventTypes().
+func (d *Device) Capabilities(evtype int) Bitset {
+	bs := NewBitset(capCount(evtype))
+	buf := bs.Bytes()
+
+	if len(buf) > 0 {
+		d.ioctl(_EVIOCGBIT(evtype, len(buf)), unsafe.Pointer(&buf[0]))
+	}
+
+	return bs
+}
+
+// capCount returns the number of codes defined for the given event type.
+func capCount(evtype int) int {
+	switch evtype {
+	case EvSync:
+		return EvCount
+	case EvKeys:
+		return KeyCount
+	case EvRelative:
+		return RelCount
+	case EvAbsolute:
+		return AbsCount
+	case EvMisc:
+		return MiscCount
+	case EvSwitch:
+		return SwCount
+	case EvLed:
+		return LedCount
+	case EvSound:
+		return SndCount
+	case EvRepeat:
+		return RepCount
+	case EvForceFeedback:
+		return FFCount
+	}
+
+	return 0
+}
+
 // IDs.
 const (
 	IdBus = iota
@@ -0,0 +1,36 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import (
+	"fmt"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// eventAt returns an event with the given timestamp, in milliseconds.
+func eventAt(evtype, code, ms int, value int32) Event {
+	return Event{
+		Time:  syscall.NsecToTimeval(int64(ms) * int64(time.Millisecond)),
+		Type:  uint16(evtype),
+		Code:  uint16(code),
+		Value: value,
+	}
+}
+
+// eventTrace formats events by name and value, to keep test tables
+// short. Key repeats include their timestamp: "KeyA 2@150".
+func eventTrace(list []Event) string {
+	var out []string
+	for _, e := range list {
+		s := fmt.Sprintf("%s %d", CodeName(int(e.Type), int(e.Code)), e.Value)
+		if e.Type == EvKeys && e.Value > 1 {
+			s += fmt.Sprintf("@%d", eventTime(e)/time.Millisecond)
+		}
+
+		out = append(out, s)
+	}
+	return strings.Join(out, "; ")
+}
@@ -11,8 +11,8 @@ import (
 )
 
 var (
-	keyboards []*evdev.Device
-	mice      []*evdev.Device
+	keyboard *evdev.Device
+	mouse    *evdev.Device
 )
 
 func main() {
@@ -20,32 +20,43 @@ func main() {
 
 	defer cleanup()
 
-	keyboards, err = evdev.Find(evdev.Keyboard)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Find keyboards: %v\n", err)
+	keyboards, err := evdev.Find(evdev.Keyboard)
+	if !report("Find keyboards", err) {
 		return
 	}
 
-	mice, err = evdev.Find(evdev.Mouse)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Find mice: %v\n", err)
+	mice, err := evdev.Find(evdev.Mouse)
+	if !report("Find mice", err) {
 		return
 	}
 
 	fmt.Printf("Keyboards: %d\n", len(keyboards))
-	for _, dev := range keyboards {
-		fmt.Printf(" - %s at %s\n", dev.Name(), dev.Path())
+	for _, ref := range keyboards {
+		fmt.Printf(" - %s at %s\n", ref.Name, ref.Node)
 	}
 
-	fmt.Printf("Mice: %d\n", len(keyboards))
-	for _, dev := range mice {
-		fmt.Printf(" - %s at %s\n", dev.Name(), dev.Path())
+	fmt.Printf("Mice: %d\n", len(mice))
+	for _, ref := range mice {
+		fmt.Printf(" - %s at %s\n", ref.Name, ref.Node)
 	}
 
 	if len(keyboards) == 0 || len(mice) == 0 {
 		return
 	}
 
+	// Only open the devices we actually want to read from.
+	keyboard, err = keyboards[0].Open()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Open keyboard: %v\n", err)
+		return
+	}
+
+	mouse, err = mice[0].Open()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Open mouse: %v\n", err)
+		return
+	}
+
 	// Poll for events or exit signals.
 	signals := make(chan os.Signal, 1)
 	signal.Notify(signals, os.Kill, os.Interrupt)
@@ -54,21 +65,39 @@ func main() {
 		case <-signals:
 			return
 
-		case evt := <-keyboards[0].Inbox:
+		case evt := <-keyboard.Inbox:
 			fmt.Printf("Keyboard: %v\n", evt)
 
-		case evt := <-mice[0].Inbox:
+		case evt := <-mouse.Inbox:
 			fmt.Printf("Mouse: %v\n", evt)
 		}
 	}
 }
 
+// report prints the given error, if any. It returns false if
+// the error is fatal. Inaccessible nodes are merely listed.
+func report(what string, err error) bool {
+	if errs, ok := err.(evdev.NodeErrors); ok {
+		for _, e := range errs {
+			fmt.Fprintf(os.Stderr, "%s: skipped %v\n", what, e)
+		}
+		return true
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", what, err)
+		return false
+	}
+
+	return true
+}
+
 func cleanup() {
-	for _, dev := range keyboards {
-		dev.Close()
+	if keyboard != nil {
+		keyboard.Close()
 	}
 
-	for _, dev := range mice {
-		dev.Close()
+	if mouse != nil {
+		mouse.Close()
 	}
 }
@@ -0,0 +1,47 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"github.com/jteeuwen/evdev"
+	"os"
+	"time"
+)
+
+func main() {
+	keys := evdev.NewBitset(evdev.KeyCount)
+	keys.Set(evdev.KeyH)
+	keys.Set(evdev.KeyI)
+
+	// Create a virtual keyboard, which can only type 'h' and 'i'.
+	dev, err := evdev.CreateVirtual(evdev.VirtualConfig{
+		Name: "evdev example keyboard",
+		Id:   evdev.Id{BusType: evdev.BusVirtual, Vendor: 0x1, Product: 0x1},
+		Capabilities: map[int]evdev.Bitset{
+			evdev.EvKeys: keys,
+		},
+	})
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return
+	}
+
+	// Make sure it is destroyed once we are done.
+	defer dev.Close()
+
+	nodes, _ := dev.Nodes()
+	fmt.Printf("Created %s at %v\n", dev.SysName(), nodes)
+
+	// Give the rest of the system a moment to notice the new device.
+	<-time.After(time.Second)
+
+	for _, code := range []int{evdev.KeyH, evdev.KeyI} {
+		dev.Emit(evdev.EvKeys, code, 1)
+		dev.Sync()
+		dev.Emit(evdev.EvKeys, code, 0)
+		dev.Sync()
+	}
+}
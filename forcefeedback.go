@@ -92,9 +92,15 @@ type Effect struct {
 	Direction uint16
 	Trigger   Trigger
 	Replay    Replay
-	data      unsafe.Pointer
+	_         uint16
+	data      [effectDataSize]byte // union; see Effect.Data.
 }
 
+// effectDataSize is the size of the effect-specific data.
+// This is the size of the largest member of the union in
+// struct ff_effect, which happens to be the periodic effect.
+const effectDataSize = unsafe.Sizeof(PeriodicEffect{})
+
 // Data returns the event data structure as a concrete type.
 // Its type depends on the value of Effect.Type and can be any of:
 //
@@ -103,40 +109,81 @@ type Effect struct {
 //    FFRamp     -> RampEffect
 //    FFRumble   -> RumbleEffect
 //    FFSpring   -> [2]ConditionEffect
+//    FFFriction -> [2]ConditionEffect
 //    FFDamper   -> [2]ConditionEffect
+//    FFInertia  -> [2]ConditionEffect
 //
 // This returns nil if the type was not recognized.
 func (e *Effect) Data() interface{} {
-	// FIXME(jimt): Deal with: FFFriction, FFInertia:
-	// Unsure what they should return.
-
-	if e.data == nil {
-		return nil
-	}
-
 	switch e.Type {
 	case FFConstant:
-		return *(*ConstantEffect)(e.data)
+		var v ConstantEffect
+		e.load(unsafe.Pointer(&v), unsafe.Sizeof(v))
+		return v
 	case FFPeriodic:
-		return *(*PeriodicEffect)(e.data)
+		var v PeriodicEffect
+		e.load(unsafe.Pointer(&v), unsafe.Sizeof(v))
+		return v
 	case FFRamp:
-		return *(*RampEffect)(e.data)
+		var v RampEffect
+		e.load(unsafe.Pointer(&v), unsafe.Sizeof(v))
+		return v
 	case FFRumble:
-		return *(*RumbleEffect)(e.data)
-	case FFSpring, FFDamper:
-		return *(*[2]ConditionEffect)(e.data)
+		var v RumbleEffect
+		e.load(unsafe.Pointer(&v), unsafe.Sizeof(v))
+		return v
+	case FFSpring, FFFriction, FFDamper, FFInertia:
+		var v [2]ConditionEffect
+		e.load(unsafe.Pointer(&v), unsafe.Sizeof(v))
+		return v
 	}
 
 	return nil
 }
 
 // SetData sets the event data structure.
+// The value must be one of the types listed for Effect.Data,
+// or a pointer to one. Anything else is ignored.
+//
+// The custom waveform of a PeriodicEffect is not copied; only
+// a reference to it. Keep it around for as long as the effect is in use.
 func (e *Effect) SetData(v interface{}) {
-	if v != nil {
-		e.data = unsafe.Pointer(&v)
+	switch vv := v.(type) {
+	case ConstantEffect:
+		e.store(unsafe.Pointer(&vv), unsafe.Sizeof(vv))
+	case *ConstantEffect:
+		e.store(unsafe.Pointer(vv), unsafe.Sizeof(*vv))
+	case PeriodicEffect:
+		e.store(unsafe.Pointer(&vv), unsafe.Sizeof(vv))
+	case *PeriodicEffect:
+		e.store(unsafe.Pointer(vv), unsafe.Sizeof(*vv))
+	case RampEffect:
+		e.store(unsafe.Pointer(&vv), unsafe.Sizeof(vv))
+	case *RampEffect:
+		e.store(unsafe.Pointer(vv), unsafe.Sizeof(*vv))
+	case RumbleEffect:
+		e.store(unsafe.Pointer(&vv), unsafe.Sizeof(vv))
+	case *RumbleEffect:
+		e.store(unsafe.Pointer(vv), unsafe.Sizeof(*vv))
+	case [2]ConditionEffect:
+		e.store(unsafe.Pointer(&vv), unsafe.Sizeof(vv))
+	case *[2]ConditionEffect:
+		e.store(unsafe.Pointer(vv), unsafe.Sizeof(*vv))
 	}
 }
 
+// load copies the effect data into the value at the given address.
+// The data is copied byte-wise, as it need not be properly aligned.
+func (e *Effect) load(v unsafe.Pointer, size uintptr) {
+	copy(unsafe.Slice((*byte)(v), size), e.data[:])
+}
+
+// store copies the value at the given address into the effect data.
+func (e *Effect) store(v unsafe.Pointer, size uintptr) {
+	e.data = [effectDataSize]byte{}
+	copy(e.data[:], unsafe.Slice((*byte)(v), size))
+}
+
 type Replay struct {
 	Length uint16
 	Delay  uint16
@@ -232,12 +279,10 @@ type RumbleEffect struct {
 //
 // This is only applicable to devices with EvForceFeedback event support.
 func (d *Device) ForceFeedbackCaps() (int, Bitset) {
-	bs := NewBitset(24)
-	buf := bs.Bytes()
-	ioctl(d.fd.Fd(), _EVIOCGBIT(EvForceFeedback, len(buf)), unsafe.Pointer(&buf[0]))
+	bs := d.Capabilities(EvForceFeedback)
 
 	var count int32
-	ioctl(d.fd.Fd(), _EVIOCGEFFECTS, unsafe.Pointer(&count))
+	d.ioctl(_EVIOCGEFFECTS, unsafe.Pointer(&count))
 	return int(count), bs
 }
 
@@ -254,7 +299,7 @@ func (d *Device) ForceFeedbackCaps() (int, Bitset) {
 // This is only applicable to devices with EvForceFeedback event support.
 func (d *Device) SetEffects(list ...*Effect) bool {
 	for _, effect := range list {
-		err := ioctl(d.fd.Fd(), _EVIOCSFF, unsafe.Pointer(effect))
+		err := d.ioctl(_EVIOCSFF, unsafe.Pointer(effect))
 		if err != nil {
 			return false
 		}
@@ -270,7 +315,7 @@ func (d *Device) SetEffects(list ...*Effect) bool {
 // This is only applicable to devices with EvForceFeedback event support.
 func (d *Device) UnsetEffects(list ...*Effect) bool {
 	for _, effect := range list {
-		err := ioctl(d.fd.Fd(), _EVIOCRMFF, int(effect.Id))
+		err := d.ioctl(_EVIOCRMFF, int(effect.Id))
 		if err != nil {
 			return false
 		}
@@ -318,7 +363,7 @@ func (d *Device) setEffectFactor(factor int, code uint16) {
 	e.Type = EvForceFeedback
 	e.Code = code
 	e.Value = 0xffff * int32(factor) / 100
-	d.Outbox <- e
+	d.send(e)
 }
 
 // PlayEffect plays a previously uploaded effect.
@@ -343,5 +388,5 @@ func (d *Device) toggleEffect(id int16, play bool) {
 		e.Value = 0
 	}
 
-	d.Outbox <- e
+	d.send(e)
 }
@@ -0,0 +1,57 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import (
+	"testing"
+	"unsafe"
+)
+
+func TestEffectData(t *testing.T) {
+	var e Effect
+	var want RumbleEffect
+
+	want.StrongMagnitude = 0xc000
+	want.WeakMagnitude = 0x4000
+
+	e.Type = FFRumble
+	e.SetData(want)
+
+	have, ok := e.Data().(RumbleEffect)
+	if !ok || have != want {
+		t.Fatalf("Rumble: Want %+v, have %+v", want, e.Data())
+	}
+
+	var p PeriodicEffect
+	p.Waveform = FFSine
+	p.Period = 100
+	p.Magnitude = -0x2000
+	p.Envelope.FadeLength = 50
+
+	e.Type = FFPeriodic
+	e.SetData(&p)
+
+	hp, ok := e.Data().(PeriodicEffect)
+	if !ok || hp.Waveform != p.Waveform || hp.Period != p.Period ||
+		hp.Magnitude != p.Magnitude || hp.Envelope != p.Envelope {
+		t.Fatalf("Periodic: Want %+v, have %+v", p, e.Data())
+	}
+}
+
+func TestEffectLayout(t *testing.T) {
+	var e Effect
+	var ptr uintptr
+
+	// struct ff_effect: 16 bytes of header, followed by a union
+	// which is as large as struct ff_periodic_effect.
+	want := 16 + 24 + unsafe.Sizeof(ptr)
+
+	if have := unsafe.Sizeof(e); have != want {
+		t.Fatalf("sizeof(Effect): Want %d, have %d", want, have)
+	}
+
+	if have := unsafe.Offsetof(e.data); have != 16 {
+		t.Fatalf("offsetof(Effect.data): Want 16, have %d", have)
+	}
+}
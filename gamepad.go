@@ -0,0 +1,295 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import (
+	"fmt"
+	"math"
+)
+
+// Sides of a gamepad, for sticks and triggers.
+const (
+	GamepadLeft = iota
+	GamepadRight
+)
+
+// GamepadProfile describes the layout of a virtual gamepad:
+// its identity, buttons and axes.
+type GamepadProfile struct {
+	Name string // Device name.
+	Id   Id     // Device identity.
+
+	// Buttons lists the buttons (BtnXXX) the gamepad has.
+	// This includes the d-pad buttons, if DPadHat is false.
+	Buttons []int
+
+	// Sticks holds the X and Y axes of the left and right stick.
+	Sticks [2][2]int
+
+	// Triggers holds the axes of the left and right trigger.
+	Triggers [2]int
+
+	// TriggerButtons holds the buttons which are reported along with
+	// the left and right trigger axes, for gamepads which have them.
+	// Zero means there is no such button.
+	TriggerButtons [2]int
+
+	// DPadHat determines whether the d-pad is reported through the
+	// AbsHat0X and AbsHat0Y axes, or through the BtnDpadXXX buttons.
+	DPadHat bool
+
+	// Absolute holds the ranges of all the axes. The Value of a stick
+	// axis is its resting position; the centre of its range.
+	Absolute map[int]AbsInfo
+}
+
+// Predefined gamepad profiles. The identities and axis ranges match
+// those of the real devices, as reported by their kernel drivers, so
+// SDL and other libraries apply the proper mapping.
+var (
+	// GamepadXbox is an Xbox 360 controller, as reported by xpad.
+	GamepadXbox = &GamepadProfile{
+		Name: "Microsoft X-Box 360 pad",
+		Id:   Id{BusType: BusUSB, Vendor: 0x045e, Product: 0x028e, Version: 0x0114},
+		Buttons: []int{
+			BtnSouth, BtnEast, BtnNorth, BtnWest, BtnTL, BtnTR,
+			BtnSelect, BtnStart, BtnMode, BtnThumbL, BtnThumbR,
+		},
+		Sticks:   [2][2]int{{AbsX, AbsY}, {AbsRX, AbsRY}},
+		Triggers: [2]int{AbsZ, AbsRZ},
+		DPadHat:  true,
+		Absolute: map[int]AbsInfo{
+			AbsX:     {Minimum: -32768, Maximum: 32767, Fuzz: 16, Flat: 128},
+			AbsY:     {Minimum: -32768, Maximum: 32767, Fuzz: 16, Flat: 128},
+			AbsRX:    {Minimum: -32768, Maximum: 32767, Fuzz: 16, Flat: 128},
+			AbsRY:    {Minimum: -32768, Maximum: 32767, Fuzz: 16, Flat: 128},
+			AbsZ:     {Minimum: 0, Maximum: 255},
+			AbsRZ:    {Minimum: 0, Maximum: 255},
+			AbsHat0X: {Minimum: -1, Maximum: 1},
+			AbsHat0Y: {Minimum: -1, Maximum: 1},
+		},
+	}
+
+	// GamepadPlayStation is a DualShock 4 controller,
+	// as reported by hid-playstation.
+	GamepadPlayStation = &GamepadProfile{
+		Name: "Sony Interactive Entertainment Wireless Controller",
+		Id:   Id{BusType: BusUSB, Vendor: 0x054c, Product: 0x09cc, Version: 0x8111},
+		Buttons: []int{
+			BtnSouth, BtnEast, BtnNorth, BtnWest, BtnTL, BtnTR, BtnTL2, BtnTR2,
+			BtnSelect, BtnStart, BtnMode, BtnThumbL, BtnThumbR,
+		},
+		Sticks:         [2][2]int{{AbsX, AbsY}, {AbsRX, AbsRY}},
+		Triggers:       [2]int{AbsZ, AbsRZ},
+		TriggerButtons: [2]int{BtnTL2, BtnTR2},
+		DPadHat:        true,
+		Absolute: map[int]AbsInfo{
+			AbsX:     {Value: 128, Minimum: 0, Maximum: 255},
+			AbsY:     {Value: 128, Minimum: 0, Maximum: 255},
+			AbsRX:    {Value: 128, Minimum: 0, Maximum: 255},
+			AbsRY:    {Value: 128, Minimum: 0, Maximum: 255},
+			AbsZ:     {Minimum: 0, Maximum: 255},
+			AbsRZ:    {Minimum: 0, Maximum: 255},
+			AbsHat0X: {Minimum: -1, Maximum: 1},
+			AbsHat0Y: {Minimum: -1, Maximum: 1},
+		},
+	}
+
+	// GamepadGeneric is a generic gamepad with two sticks, analog
+	// triggers and a d-pad made of buttons.
+	GamepadGeneric = &GamepadProfile{
+		Name: "Generic Gamepad",
+		Id:   Id{BusType: BusVirtual, Vendor: 0x0001, Product: 0x0001, Version: 0x0001},
+		Buttons: []int{
+			BtnSouth, BtnEast, BtnNorth, BtnWest, BtnTL, BtnTR,
+			BtnSelect, BtnStart, BtnMode, BtnThumbL, BtnThumbR,
+			BtnDpadUp, BtnDpadDown, BtnDpadLeft, BtnDpadRight,
+		},
+		Sticks:   [2][2]int{{AbsX, AbsY}, {AbsRX, AbsRY}},
+		Triggers: [2]int{AbsZ, AbsRZ},
+		Absolute: map[int]AbsInfo{
+			AbsX:  {Minimum: -32768, Maximum: 32767, Fuzz: 16, Flat: 128},
+			AbsY:  {Minimum: -32768, Maximum: 32767, Fuzz: 16, Flat: 128},
+			AbsRX: {Minimum: -32768, Maximum: 32767, Fuzz: 16, Flat: 128},
+			AbsRY: {Minimum: -32768, Maximum: 32767, Fuzz: 16, Flat: 128},
+			AbsZ:  {Minimum: 0, Maximum: 1023},
+			AbsRZ: {Minimum: 0, Maximum: 1023},
+		},
+	}
+)
+
+// VirtualGamepad is a virtual device which behaves like the gamepad
+// described by its profile. Every call to one of its Set, Press or
+// Release methods sends a single, complete frame.
+type VirtualGamepad struct {
+	*VirtualDevice
+	Profile *GamepadProfile
+}
+
+// NewVirtualGamepad creates a virtual gamepad from the given profile.
+// If ff is not nil, the gamepad supports rumble effects, and passes
+// the requests for them on to ff.
+func NewVirtualGamepad(profile *GamepadProfile, ff *FFHandler) (*VirtualGamepad, error) {
+	keys := NewBitset(KeyCount)
+	for _, code := range profile.Buttons {
+		keys.Set(code)
+	}
+
+	cfg := VirtualConfig{
+		Name:         profile.Name,
+		Id:           profile.Id,
+		Capabilities: map[int]Bitset{EvKeys: keys},
+		Absolute:     profile.Absolute,
+	}
+
+	if ff != nil {
+		effects := NewBitset(FFCount)
+		effects.Set(FFRumble)
+		effects.Set(FFGain)
+
+		cfg.Capabilities[EvForceFeedback] = effects
+		cfg.EffectsMax = 16
+		cfg.ForceFeedback = ff
+	}
+
+	dev, err := CreateVirtual(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &VirtualGamepad{VirtualDevice: dev, Profile: profile}, nil
+}
+
+// SetStick moves the left or right stick (GamepadLeft, GamepadRight)
+// to the given position. Both x and y are in the range [-1, 1], where
+// -1 is left and up, respectively.
+func (g *VirtualGamepad) SetStick(side int, x, y float64) error {
+	if err := checkSide(side); err != nil {
+		return err
+	}
+
+	return g.frame(g.Profile.stickEvents(side, x, y)...)
+}
+
+// SetTrigger pulls the left or right trigger (GamepadLeft, GamepadRight)
+// to the given position, in the range [0, 1]. For gamepads which have
+// buttons for their triggers, the button is pressed as long as the
+// position is not 0.
+func (g *VirtualGamepad) SetTrigger(side int, v float64) error {
+	if err := checkSide(side); err != nil {
+		return err
+	}
+
+	return g.frame(g.Profile.triggerEvents(side, v)...)
+}
+
+// Press presses the given button (BtnXXX), without releasing it.
+func (g *VirtualGamepad) Press(btn int) error {
+	return g.frame(Event{Type: EvKeys, Code: uint16(btn), Value: 1})
+}
+
+// Release releases the given button.
+func (g *VirtualGamepad) Release(btn int) error {
+	return g.frame(Event{Type: EvKeys, Code: uint16(btn), Value: 0})
+}
+
+// SetDPad sets the direction of the d-pad. Both x and y are in the
+// range [-1, 1], where -1 is left and up, respectively; (0, 0)
+// releases the d-pad.
+func (g *VirtualGamepad) SetDPad(x, y int) error {
+	return g.frame(g.Profile.dpadEvents(x, y)...)
+}
+
+// checkSide returns an error if side is not a side of a gamepad.
+func checkSide(side int) error {
+	if side != GamepadLeft && side != GamepadRight {
+		return fmt.Errorf("evdev: invalid gamepad side %d", side)
+	}
+
+	return nil
+}
+
+// stickEvents returns the events which move a stick. See SetStick.
+func (p *GamepadProfile) stickEvents(side int, x, y float64) []Event {
+	axes := p.Sticks[side]
+
+	return []Event{
+		absEvent(axes[0], stickValue(x, p.Absolute[axes[0]])),
+		absEvent(axes[1], stickValue(y, p.Absolute[axes[1]])),
+	}
+}
+
+// triggerEvents returns the events which pull a trigger. See SetTrigger.
+func (p *GamepadProfile) triggerEvents(side int, v float64) []Event {
+	code := p.Triggers[side]
+	list := []Event{absEvent(code, triggerValue(v, p.Absolute[code]))}
+
+	if btn := p.TriggerButtons[side]; btn != 0 {
+		list = append(list, btnEvent(btn, v > 0))
+	}
+
+	return list
+}
+
+// dpadEvents returns the events which set the d-pad. See SetDPad.
+func (p *GamepadProfile) dpadEvents(x, y int) []Event {
+	x, y = sign(x), sign(y)
+
+	if p.DPadHat {
+		return []Event{absEvent(AbsHat0X, int32(x)), absEvent(AbsHat0Y, int32(y))}
+	}
+
+	return []Event{
+		btnEvent(BtnDpadUp, y < 0),
+		btnEvent(BtnDpadDown, y > 0),
+		btnEvent(BtnDpadLeft, x < 0),
+		btnEvent(BtnDpadRight, x > 0),
+	}
+}
+
+// absEvent returns an event for the given axis.
+func absEvent(code int, value int32) Event {
+	return Event{Type: EvAbsolute, Code: uint16(code), Value: value}
+}
+
+// btnEvent returns an event which presses or releases the given button.
+func btnEvent(code int, pressed bool) Event {
+	var v int32
+	if pressed {
+		v = 1
+	}
+	return Event{Type: EvKeys, Code: uint16(code), Value: v}
+}
+
+// frame sends the given events, followed by a SynReport.
+func (g *VirtualGamepad) frame(list ...Event) error {
+	list = append(list, Event{Type: EvSync, Code: SynReport})
+	return g.WriteEvents(list...)
+}
+
+// stickValue maps v in the range [-1, 1] onto the given axis.
+// 0 maps onto the center of the axis.
+func stickValue(v float64, axis AbsInfo) int32 {
+	v = math.Max(-1, math.Min(1, v))
+	span := float64(axis.Maximum) - float64(axis.Minimum)
+	return axis.Minimum + int32(math.Floor((v+1)/2*span+0.5))
+}
+
+// triggerValue maps v in the range [0, 1] onto the given axis.
+func triggerValue(v float64, axis AbsInfo) int32 {
+	v = math.Max(0, math.Min(1, v))
+	span := float64(axis.Maximum) - float64(axis.Minimum)
+	return axis.Minimum + int32(math.Floor(v*span+0.5))
+}
+
+// sign returns -1, 0 or 1, depending on the sign of v.
+func sign(v int) int {
+	switch {
+	case v < 0:
+		return -1
+	case v > 0:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,79 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import "testing"
+
+func TestGamepadAxes(t *testing.T) {
+	xbox := AbsInfo{Minimum: -32768, Maximum: 32767}
+	ds4 := AbsInfo{Minimum: 0, Maximum: 255}
+
+	sticks := []struct {
+		v    float64
+		axis AbsInfo
+		want int32
+	}{
+		{-1, xbox, -32768},
+		{0, xbox, 0},
+		{1, xbox, 32767},
+		{-2, xbox, -32768},
+		{-1, ds4, 0},
+		{0, ds4, 128},
+		{1, ds4, 255},
+	}
+
+	for _, tt := range sticks {
+		if got := stickValue(tt.v, tt.axis); got != tt.want {
+			t.Fatalf("stickValue(%v, %v): Want %d, have %d", tt.v, tt.axis, tt.want, got)
+		}
+	}
+
+	if got := triggerValue(0.5, ds4); got != 128 {
+		t.Fatalf("triggerValue: Want 128, have %d", got)
+	}
+
+	if got := triggerValue(1.5, ds4); got != 255 {
+		t.Fatalf("triggerValue: Want 255, have %d", got)
+	}
+}
+
+func TestGamepadProfiles(t *testing.T) {
+	for _, p := range []*GamepadProfile{GamepadXbox, GamepadPlayStation, GamepadGeneric} {
+		for _, side := range p.Sticks {
+			for _, code := range side {
+				info, ok := p.Absolute[code]
+				if !ok {
+					t.Fatalf("%s: Missing stick axis %#x", p.Name, code)
+				}
+
+				if centre := stickValue(0, info); info.Value != centre {
+					t.Fatalf("%s: Axis %#x: Want value %d, have %d", p.Name, code, centre, info.Value)
+				}
+			}
+		}
+
+		for _, code := range p.Triggers {
+			if _, ok := p.Absolute[code]; !ok {
+				t.Fatalf("%s: Missing trigger axis %#x", p.Name, code)
+			}
+		}
+
+		_, hat := p.Absolute[AbsHat0X]
+		if hat != p.DPadHat {
+			t.Fatalf("%s: DPadHat: Want %v, have %v", p.Name, hat, p.DPadHat)
+		}
+	}
+}
+
+func TestGamepadSide(t *testing.T) {
+	g := &VirtualGamepad{Profile: GamepadXbox}
+
+	if err := g.SetStick(2, 0, 0); err == nil {
+		t.Fatalf("SetStick: Expected error for invalid side")
+	}
+
+	if err := g.SetTrigger(-1, 0); err == nil {
+		t.Fatalf("SetTrigger: Expected error for invalid side")
+	}
+}
@@ -3,6 +3,8 @@
 
 package evdev
 
+import "unsafe"
+
 // Multitouch tools
 const (
 	MtToolFinger = 0
@@ -69,3 +71,12 @@ const (
 	InputPropMax   = 0x1f
 	InputPropCount = InputPropMax + 1
 )
+
+// Properties returns a bitset indicating which InputPropXXX
+// properties are defined for the device.
+func (d *Device) Properties() Bitset {
+	bs := NewBitset(InputPropCount)
+	buf := bs.Bytes()
+	d.ioctl(_EVIOCGPROP(len(buf)), unsafe.Pointer(&buf[0]))
+	return bs
+}
@@ -5,10 +5,40 @@ package evdev
 
 import (
 	"fmt"
+	"os"
 	"syscall"
 	"unsafe"
 )
 
+// control calls fn with the descriptor of the given file. Unlike
+// File.Fd, this leaves the file in non-blocking mode; so a pending
+// read can still be interrupted by closing the file. The descriptor
+// stays valid until fn returns, even if the file is closed meanwhile.
+func control(fd *os.File, fn func(sysfd uintptr) error) error {
+	conn, err := fd.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var ferr error
+	err = conn.Control(func(sysfd uintptr) {
+		ferr = fn(sysfd)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return ferr
+}
+
+// fileIoctl performs an ioctl on the given file. See control.
+func fileIoctl(fd *os.File, name uintptr, data interface{}) error {
+	return control(fd, func(sysfd uintptr) error {
+		return ioctl(sysfd, name, data)
+	})
+}
+
 func ioctl(fd, name uintptr, data interface{}) error {
 	var v uintptr
 
@@ -48,6 +78,29 @@ var (
 	_EVIOCGEFFECTS    uintptr
 	_EVIOCGRAB        uintptr
 	_EVIOCSCLOCKID    uintptr
+	_EVIOCSMASK       uintptr
+
+	_UI_DEV_CREATE  uintptr
+	_UI_DEV_DESTROY uintptr
+	_UI_DEV_SETUP   uintptr
+	_UI_ABS_SETUP   uintptr
+	_UI_SET_EVBIT   uintptr
+	_UI_SET_KEYBIT  uintptr
+	_UI_SET_RELBIT  uintptr
+	_UI_SET_ABSBIT  uintptr
+	_UI_SET_MSCBIT  uintptr
+	_UI_SET_LEDBIT  uintptr
+	_UI_SET_SNDBIT  uintptr
+	_UI_SET_FFBIT   uintptr
+	_UI_SET_PHYS    uintptr
+	_UI_SET_SWBIT   uintptr
+	_UI_SET_PROPBIT uintptr
+	_UI_GET_VERSION uintptr
+
+	_UI_BEGIN_FF_UPLOAD uintptr
+	_UI_END_FF_UPLOAD   uintptr
+	_UI_BEGIN_FF_ERASE  uintptr
+	_UI_END_FF_ERASE    uintptr
 )
 
 func init() {
@@ -55,12 +108,24 @@ func init() {
 	var id Id
 	var ke KeymapEntry
 	var ffe Effect
+	var mask inputMask
+	var setup uinputSetup
+	var abs uinputAbsSetup
+	var ptr uintptr
+	var upload uinputFFUpload
+	var erase uinputFFErase
 
 	sizeof_int := int(unsafe.Sizeof(i))
 	sizeof_int2 := sizeof_int << 1
 	sizeof_id := int(unsafe.Sizeof(id))
 	sizeof_keymap_entry := int(unsafe.Sizeof(ke))
 	sizeof_effect := int(unsafe.Sizeof(ffe))
+	sizeof_mask := int(unsafe.Sizeof(mask))
+	sizeof_setup := int(unsafe.Sizeof(setup))
+	sizeof_abs_setup := int(unsafe.Sizeof(abs))
+	sizeof_ptr := int(unsafe.Sizeof(ptr))
+	sizeof_upload := int(unsafe.Sizeof(upload))
+	sizeof_erase := int(unsafe.Sizeof(erase))
 
 	_EVIOCGVERSION = _IOR('E', 0x01, sizeof_int)
 	_EVIOCGID = _IOR('E', 0x02, sizeof_id)
@@ -76,7 +141,34 @@ func init() {
 	_EVIOCRMFF = _IOW('E', 0x81, sizeof_int)
 	_EVIOCGEFFECTS = _IOR('E', 0x84, sizeof_int)
 	_EVIOCGRAB = _IOW('E', 0x90, sizeof_int)
+	_EVIOCSMASK = _IOW('E', 0x93, sizeof_mask)
 	_EVIOCSCLOCKID = _IOW('E', 0xa0, sizeof_int)
+
+	_UI_DEV_CREATE = _IO('U', 1)
+	_UI_DEV_DESTROY = _IO('U', 2)
+	_UI_DEV_SETUP = _IOW('U', 3, sizeof_setup)
+	_UI_ABS_SETUP = _IOW('U', 4, sizeof_abs_setup)
+	_UI_SET_EVBIT = _IOW('U', 100, sizeof_int)
+	_UI_SET_KEYBIT = _IOW('U', 101, sizeof_int)
+	_UI_SET_RELBIT = _IOW('U', 102, sizeof_int)
+	_UI_SET_ABSBIT = _IOW('U', 103, sizeof_int)
+	_UI_SET_MSCBIT = _IOW('U', 104, sizeof_int)
+	_UI_SET_LEDBIT = _IOW('U', 105, sizeof_int)
+	_UI_SET_SNDBIT = _IOW('U', 106, sizeof_int)
+	_UI_SET_FFBIT = _IOW('U', 107, sizeof_int)
+	_UI_SET_PHYS = _IOW('U', 108, sizeof_ptr)
+	_UI_SET_SWBIT = _IOW('U', 109, sizeof_int)
+	_UI_SET_PROPBIT = _IOW('U', 110, sizeof_int)
+	_UI_GET_VERSION = _IOR('U', 45, sizeof_int)
+
+	_UI_BEGIN_FF_UPLOAD = _IOWR('U', 200, sizeof_upload)
+	_UI_END_FF_UPLOAD = _IOW('U', 201, sizeof_upload)
+	_UI_BEGIN_FF_ERASE = _IOWR('U', 202, sizeof_erase)
+	_UI_END_FF_ERASE = _IOW('U', 203, sizeof_erase)
+}
+
+func _UI_GET_SYSNAME(len int) uintptr {
+	return _IOC(_IOC_READ, 'U', 44, len)
 }
 
 func _EVIOCGNAME(len int) uintptr {
@@ -0,0 +1,151 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import "time"
+
+// DefaultKeyDelay is the default pause between two keystrokes
+// typed by a VirtualKeyboard.
+const DefaultKeyDelay = 10 * time.Millisecond
+
+// VirtualKeyboard is a virtual device which types text.
+//
+// It maps characters onto keystrokes through a KeyboardLayout.
+// This layout must match the one the receiving end (e.g. the X server
+// or Wayland compositor) has configured for the keyboard; otherwise
+// the wrong characters come out.
+type VirtualKeyboard struct {
+	*VirtualDevice
+
+	// Layout determines the keys used to type each character.
+	Layout *KeyboardLayout
+
+	// Delay is the pause between two keystrokes.
+	// It defaults to DefaultKeyDelay.
+	Delay time.Duration
+
+	// Hold is the time each key is held down before being released.
+	// Some applications miss keys which are released too soon.
+	Hold time.Duration
+}
+
+// NewVirtualKeyboard creates a virtual keyboard with the given name.
+// If layout is nil, LayoutUS is used.
+func NewVirtualKeyboard(name string, layout *KeyboardLayout) (*VirtualKeyboard, error) {
+	if layout == nil {
+		layout = LayoutUS
+	}
+
+	keys := NewBitset(KeyCount)
+	for code := KeyEscape; code < BtnMisc; code++ {
+		keys.Set(code)
+	}
+
+	// The lock LEDs make sure the rest of the system
+	// recognizes us as a keyboard. See IsKeyboard.
+	leds := NewBitset(LedCount)
+	leds.Set(LedNumLock)
+	leds.Set(LedCapsLock)
+	leds.Set(LedScrollLock)
+
+	dev, err := CreateVirtual(VirtualConfig{
+		Name: name,
+		Id:   Id{BusType: BusVirtual},
+		Capabilities: map[int]Bitset{
+			EvKeys: keys,
+			EvLed:  leds,
+		},
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &VirtualKeyboard{
+		VirtualDevice: dev,
+		Layout:        layout,
+		Delay:         DefaultKeyDelay,
+	}, nil
+}
+
+// Press presses the given key, without releasing it.
+func (k *VirtualKeyboard) Press(code int) error {
+	return k.key(code, 1)
+}
+
+// Release releases the given key.
+func (k *VirtualKeyboard) Release(code int) error {
+	return k.key(code, 0)
+}
+
+// Tap presses the given keys in order and releases them in reverse
+// order. E.g.: Tap(KeyLeftCtrl, KeyC) sends Ctrl+C.
+func (k *VirtualKeyboard) Tap(codes ...int) error {
+	for _, code := range codes {
+		if err := k.Press(code); err != nil {
+			return err
+		}
+	}
+
+	time.Sleep(k.Hold)
+
+	for i := len(codes) - 1; i >= 0; i-- {
+		if err := k.Release(codes[i]); err != nil {
+			return err
+		}
+	}
+
+	time.Sleep(k.Delay)
+	return nil
+}
+
+// Stroke types the given keystroke, holding down the
+// necessary modifiers.
+func (k *VirtualKeyboard) Stroke(ks KeyStroke) error {
+	codes := make([]int, 0, 3)
+
+	if ks.Mods&ModShift != 0 {
+		codes = append(codes, KeyLeftShift)
+	}
+
+	if ks.Mods&ModAltGr != 0 {
+		codes = append(codes, KeyRightAlt)
+	}
+
+	return k.Tap(append(codes, ks.Code)...)
+}
+
+// Type types the given text. Every character is checked against the
+// layout before anything is typed. If one of them can not be typed,
+// an error is returned and nothing is sent.
+func (k *VirtualKeyboard) Type(text string) error {
+	var list []KeyStroke
+
+	for _, r := range text {
+		ks, err := k.Layout.Strokes(r)
+		if err != nil {
+			return err
+		}
+
+		list = append(list, ks...)
+	}
+
+	for _, ks := range list {
+		if err := k.Stroke(ks); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// key sends a single key event, followed by a SynReport.
+func (k *VirtualKeyboard) key(code int, value int32) error {
+	err := k.Emit(EvKeys, code, value)
+	if err == nil {
+		err = k.Sync()
+	}
+
+	return err
+}
@@ -0,0 +1,312 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import (
+	"fmt"
+	"math"
+)
+
+// GamepadDPad targets the d-pad of a gamepad, rather than
+// one of its sticks. See KeyDirections.
+const GamepadDPad = GamepadRight + 1
+
+// Ways of resolving simultaneous opposing cardinal directions (SOCD):
+// what happens when left and right, or up and down, are held at once.
+const (
+	// SOCDNeutral centres the axis.
+	SOCDNeutral = iota
+
+	// SOCDLastWins follows the direction pressed last.
+	SOCDLastWins
+
+	// SOCDFirstWins keeps the direction pressed first.
+	SOCDFirstWins
+
+	// SOCDUpPriority favours up over down, and centres left and right.
+	// This is the rule used by most tournament controllers.
+	SOCDUpPriority
+)
+
+// KeyDirections maps four keys, such as WASD or the arrow keys,
+// onto a stick or the d-pad.
+type KeyDirections struct {
+	Up, Down, Left, Right int // The keys for each direction.
+
+	// Target is the stick (GamepadLeft, GamepadRight) or the
+	// d-pad (GamepadDPad) the keys drive.
+	Target int
+
+	// SOCD determines what happens when opposing directions are
+	// held at the same time. The default is SOCDNeutral.
+	SOCD int
+}
+
+// KeyGamepadMapping describes how the keys of a keyboard drive
+// a virtual gamepad.
+type KeyGamepadMapping struct {
+	// Directions lists the groups of keys which drive the sticks
+	// and the d-pad.
+	Directions []KeyDirections
+
+	// Buttons maps keys onto gamepad buttons (BtnXXX).
+	Buttons map[int]int
+
+	// Triggers maps keys onto the left and right triggers
+	// (GamepadLeft, GamepadRight), which are pulled fully
+	// while the key is held.
+	Triggers map[int]int
+}
+
+// Filter returns a filter which applies the mapping to the events of
+// a keyboard. It produces the events for a virtual gamepad with the
+// given profile; such as the one created by NewKeyGamepadMapper.
+// Key repeats and events other than key events are dropped. It returns
+// an error if the mapping refers to a stick, trigger or button the
+// profile does not have.
+func (m *KeyGamepadMapping) Filter(profile *GamepadProfile) (Filter, error) {
+	if err := m.check(profile); err != nil {
+		return nil, err
+	}
+
+	f := newKeyGamepadFilter(m, profile)
+
+	return FilterFunc(func(in Frame) []Frame {
+		var out Frame
+		for _, e := range in {
+			out = append(out, f.apply(e)...)
+		}
+
+		if len(out) == 0 {
+			return nil
+		}
+
+		return []Frame{out}
+	}), nil
+}
+
+// check returns an error if the mapping does not suit the profile.
+func (m *KeyGamepadMapping) check(profile *GamepadProfile) error {
+	has := make(map[int]bool)
+	for _, btn := range profile.Buttons {
+		has[btn] = true
+	}
+
+	for _, g := range m.Directions {
+		switch g.Target {
+		case GamepadLeft, GamepadRight:
+		case GamepadDPad:
+			if profile.DPadHat {
+				continue
+			}
+
+			for _, btn := range [...]int{BtnDpadUp, BtnDpadDown, BtnDpadLeft, BtnDpadRight} {
+				if !has[btn] {
+					return fmt.Errorf("evdev: gamepad %q has no d-pad button %s", profile.Name, CodeName(EvKeys, btn))
+				}
+			}
+		default:
+			return fmt.Errorf("evdev: invalid key direction target %d", g.Target)
+		}
+	}
+
+	for _, btn := range m.Buttons {
+		if !has[btn] {
+			return fmt.Errorf("evdev: gamepad %q has no button %s", profile.Name, CodeName(EvKeys, btn))
+		}
+	}
+
+	for _, side := range m.Triggers {
+		if err := checkSide(side); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// keyGamepadFilter holds the state of KeyGamepadMapping.Filter.
+type keyGamepadFilter struct {
+	m       *KeyGamepadMapping
+	profile *GamepadProfile
+	held    [][]int  // Per group: the directions held, in order.
+	pos     [][2]int // Per group: the resolved position.
+}
+
+func newKeyGamepadFilter(m *KeyGamepadMapping, profile *GamepadProfile) *keyGamepadFilter {
+	return &keyGamepadFilter{
+		m:       m,
+		profile: profile,
+		held:    make([][]int, len(m.Directions)),
+		pos:     make([][2]int, len(m.Directions)),
+	}
+}
+
+// apply returns the gamepad events for a single keyboard event.
+func (f *keyGamepadFilter) apply(e Event) []Event {
+	if e.Type != EvKeys || e.Value > 1 {
+		return nil
+	}
+
+	code := int(e.Code)
+	down := e.Value == 1
+	var out []Event
+
+	if btn, ok := f.m.Buttons[code]; ok {
+		out = append(out, btnEvent(btn, down))
+	}
+
+	if side, ok := f.m.Triggers[code]; ok {
+		v := 0.0
+		if down {
+			v = 1
+		}
+
+		out = append(out, f.profile.triggerEvents(side, v)...)
+	}
+
+	for i, g := range f.m.Directions {
+		dir := -1
+		for d, key := range [4]int{g.Up, g.Down, g.Left, g.Right} {
+			if key == code {
+				dir = d
+			}
+		}
+
+		if dir < 0 {
+			continue
+		}
+
+		if down && !hasKey(f.held[i], dir) {
+			f.held[i] = append(f.held[i], dir)
+		} else if !down {
+			f.held[i] = removeKey(f.held[i], dir)
+		}
+
+		pos := [2]int{
+			socd(f.held[i], 2, 3, g.SOCD, false),
+			socd(f.held[i], 0, 1, g.SOCD, true),
+		}
+
+		if pos == f.pos[i] {
+			continue
+		}
+
+		f.pos[i] = pos
+
+		if g.Target == GamepadDPad {
+			out = append(out, f.profile.dpadEvents(pos[0], pos[1])...)
+			continue
+		}
+
+		// Keep diagonals within the circle a stick can reach.
+		x, y := float64(pos[0]), float64(pos[1])
+		if x != 0 && y != 0 {
+			x, y = x*math.Sqrt2/2, y*math.Sqrt2/2
+		}
+
+		out = append(out, f.profile.stickEvents(g.Target, x, y)...)
+	}
+
+	for i := range out {
+		out[i].Time = e.Time
+	}
+
+	return out
+}
+
+// socd resolves an axis from the directions which are held, in the
+// order they were pressed. neg and pos are the directions which make
+// the axis negative and positive. vertical is set for the up/down axis.
+func socd(held []int, neg, pos, mode int, vertical bool) int {
+	first, last := 0, 0
+
+	for _, d := range held {
+		var v int
+		switch d {
+		case neg:
+			v = -1
+		case pos:
+			v = 1
+		default:
+			continue
+		}
+
+		if first == 0 {
+			first = v
+		}
+		last = v
+	}
+
+	if first == last {
+		return first
+	}
+
+	// Both directions are held.
+	switch mode {
+	case SOCDLastWins:
+		return last
+	case SOCDFirstWins:
+		return first
+	case SOCDUpPriority:
+		if vertical {
+			return -1
+		}
+	}
+
+	return 0
+}
+
+// KeyGamepadMapper grabs a keyboard and turns its keys into the
+// buttons and axes of a virtual gamepad, according to a mapping.
+type KeyGamepadMapper struct {
+	g      *grabber
+	pad    *VirtualGamepad
+	filter Filter
+}
+
+// NewKeyGamepadMapper creates a mapper for the given keyboard, which
+// takes ownership of it; the keyboard is closed if this fails. The
+// virtual gamepad is created from the given profile; which must have the
+// buttons the mapping refers to, or an error is returned. The keyboard
+// is grabbed as by NewRemapper.
+func NewKeyGamepadMapper(dev *Device, m *KeyGamepadMapping, profile *GamepadProfile) (*KeyGamepadMapper, error) {
+	filter, err := m.Filter(profile)
+	if err != nil {
+		dev.Close()
+		return nil, err
+	}
+
+	pad, err := NewVirtualGamepad(profile, nil)
+	if err != nil {
+		dev.Close()
+		return nil, err
+	}
+
+	g, err := newGrabber(pad.VirtualDevice, true, dev)
+	if err != nil {
+		return nil, err
+	}
+
+	return &KeyGamepadMapper{g: g, pad: pad, filter: filter}, nil
+}
+
+// Gamepad returns the virtual gamepad.
+func (k *KeyGamepadMapper) Gamepad() *VirtualGamepad {
+	return k.pad
+}
+
+// Run maps events until the mapper is closed, or until an error
+// occurs; like Remapper.Run.
+func (k *KeyGamepadMapper) Run() error {
+	return k.g.run(func() error {
+		return NewPipeline(NewDeviceSource(k.g.devs[0]), k.pad, k.filter).Run()
+	})
+}
+
+// Close stops the mapper. It releases the grab, closes the keyboard
+// and destroys the virtual gamepad.
+func (k *KeyGamepadMapper) Close() error {
+	return k.g.Close()
+}
@@ -0,0 +1,144 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import "testing"
+
+func TestSOCD(t *testing.T) {
+	const up, down = 0, 1
+
+	tests := []struct {
+		held     []int
+		mode     int
+		vertical bool
+		want     int
+	}{
+		{nil, SOCDNeutral, true, 0},
+		{[]int{up}, SOCDNeutral, true, -1},
+		{[]int{down}, SOCDFirstWins, true, 1},
+		{[]int{up, down}, SOCDNeutral, true, 0},
+		{[]int{up, down}, SOCDLastWins, true, 1},
+		{[]int{down, up}, SOCDLastWins, true, -1},
+		{[]int{up, down}, SOCDFirstWins, true, -1},
+		{[]int{down, up}, SOCDFirstWins, true, 1},
+		{[]int{down, up}, SOCDUpPriority, true, -1},
+		{[]int{down, up}, SOCDUpPriority, false, 0},
+		{[]int{2, 3, down}, SOCDNeutral, true, 1},
+	}
+
+	for i, tt := range tests {
+		if got := socd(tt.held, up, down, tt.mode, tt.vertical); got != tt.want {
+			t.Fatalf("Test %d: Want %d, have %d", i, tt.want, got)
+		}
+	}
+}
+
+func TestKeyGamepadMapping(t *testing.T) {
+	m := &KeyGamepadMapping{
+		Directions: []KeyDirections{
+			{Up: KeyW, Down: KeyS, Left: KeyA, Right: KeyD, Target: GamepadLeft, SOCD: SOCDLastWins},
+			{Up: KeyUp, Down: KeyDown, Left: KeyLeft, Right: KeyRight, Target: GamepadDPad, SOCD: SOCDUpPriority},
+		},
+		Buttons:  map[int]int{KeySpace: BtnSouth},
+		Triggers: map[int]int{KeyQ: GamepadLeft},
+	}
+
+	tests := []struct {
+		profile *GamepadProfile
+		events  []Event
+		want    []string
+	}{
+		{
+			GamepadXbox,
+			[]Event{key(KeyD, 1), key(KeyA, 1), key(KeyD, 2), key(KeyW, 1), key(KeyA, 0), key(KeyW, 0), key(KeyD, 0)},
+			[]string{
+				"AbsX 32767; AbsY 0",
+				"AbsX -32768; AbsY 0",
+				"",
+				"AbsX -23171; AbsY -23171",
+				"AbsX 23170; AbsY -23171",
+				"AbsX 32767; AbsY 0",
+				"AbsX 0; AbsY 0",
+			},
+		},
+		{
+			GamepadXbox,
+			[]Event{key(KeyDown, 1), key(KeyUp, 1), key(KeyLeft, 1), key(KeyRight, 1), key(KeyUp, 0)},
+			[]string{
+				"AbsHat0X 0; AbsHat0Y 1",
+				"AbsHat0X 0; AbsHat0Y -1",
+				"AbsHat0X -1; AbsHat0Y -1",
+				"AbsHat0X 0; AbsHat0Y -1",
+				"AbsHat0X 0; AbsHat0Y 1",
+			},
+		},
+		{
+			GamepadGeneric,
+			[]Event{key(KeyLeft, 1), key(KeyLeft, 0)},
+			[]string{
+				"BtnDpadUp 0; BtnDpadDown 0; BtnDpadLeft 1; BtnDpadRight 0",
+				"BtnDpadUp 0; BtnDpadDown 0; BtnDpadLeft 0; BtnDpadRight 0",
+			},
+		},
+		{
+			GamepadPlayStation,
+			[]Event{key(KeySpace, 1), key(KeyQ, 1), key(KeyQ, 0), key(KeySpace, 0), key(KeyZ, 1)},
+			[]string{
+				"BtnA 1",
+				"AbsZ 255; BtnTL2 1",
+				"AbsZ 0; BtnTL2 0",
+				"BtnA 0",
+				"",
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		if err := m.check(tt.profile); err != nil {
+			t.Fatalf("Test %d: %v", i, err)
+		}
+
+		f := newKeyGamepadFilter(m, tt.profile)
+
+		for j, e := range tt.events {
+			if got := eventTrace(f.apply(e)); got != tt.want[j] {
+				t.Fatalf("Test %d, event %d: Want %q, have %q", i, j, tt.want[j], got)
+			}
+		}
+	}
+}
+
+func TestKeyGamepadCheck(t *testing.T) {
+	tests := []struct {
+		profile *GamepadProfile
+		m       KeyGamepadMapping
+	}{
+		{GamepadXbox, KeyGamepadMapping{Directions: []KeyDirections{{Target: 3}}}},
+		{GamepadXbox, KeyGamepadMapping{Directions: []KeyDirections{{Target: -1}}}},
+		{GamepadXbox, KeyGamepadMapping{Buttons: map[int]int{KeyA: BtnTL2}}},
+		{GamepadXbox, KeyGamepadMapping{Buttons: map[int]int{KeyA: BtnDpadUp}}},
+		{GamepadXbox, KeyGamepadMapping{Triggers: map[int]int{KeyQ: 2}}},
+		{GamepadPlayStation, KeyGamepadMapping{Triggers: map[int]int{KeyQ: GamepadDPad}}},
+		{
+			&GamepadProfile{Name: "No d-pad", Buttons: []int{BtnSouth}},
+			KeyGamepadMapping{Directions: []KeyDirections{{Target: GamepadDPad}}},
+		},
+	}
+
+	for i, tt := range tests {
+		if _, err := tt.m.Filter(tt.profile); err == nil {
+			t.Fatalf("Test %d: Expected error", i)
+		}
+	}
+
+	m := KeyGamepadMapping{
+		Directions: []KeyDirections{{Target: GamepadDPad}, {Target: GamepadRight}},
+		Buttons:    map[int]int{KeyA: BtnDpadUp, KeyB: BtnMode},
+		Triggers:   map[int]int{KeyQ: GamepadRight},
+	}
+
+	if _, err := m.Filter(GamepadGeneric); err != nil {
+		t.Fatalf("Want <nil>, have %v", err)
+	}
+}
@@ -0,0 +1,534 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import (
+	"math"
+	"syscall"
+	"time"
+)
+
+// Default timeouts for KeyProcessor.
+const (
+	DefaultTapTimeout   = 200 * time.Millisecond
+	DefaultChordTimeout = 50 * time.Millisecond
+)
+
+// Kinds of Action.
+const (
+	actTransparent = iota
+	actNone
+	actKey
+	actLayer
+	actTapHold
+	actOneShot
+)
+
+// Action describes what a key does on a given layer of a KeyProcessor.
+// The zero value is ActTransparent.
+type Action struct {
+	kind int
+	code int     // Key code, layer number or modifier.
+	hold *Action // Hold action of a tap-hold key.
+}
+
+// Actions which need no arguments.
+var (
+	// ActTransparent defers to the next active layer below.
+	// This is what keys missing from a layer do.
+	ActTransparent = Action{kind: actTransparent}
+
+	// ActNone disables the key.
+	ActNone = Action{kind: actNone}
+)
+
+// ActKey makes the key produce the given key code.
+func ActKey(code int) Action {
+	return Action{kind: actKey, code: code}
+}
+
+// ActLayer activates the given layer for as long as the key is held.
+func ActLayer(layer int) Action {
+	return Action{kind: actLayer, code: layer}
+}
+
+// ActTapHold makes a dual-role key: it produces the given key code
+// when tapped, and performs the hold action when held. E.g.:
+// ActTapHold(KeySpace, ActKey(KeyLeftShift)) is Space on tap and
+// Shift on hold. The hold action may not be a tap-hold itself.
+func ActTapHold(tap int, hold Action) Action {
+	return Action{kind: actTapHold, code: tap, hold: &hold}
+}
+
+// ActOneShot makes a one-shot modifier. Tapping the key applies the
+// modifier to the next key press only; holding it down makes it act
+// like a regular modifier.
+func ActOneShot(mod int) Action {
+	return Action{kind: actOneShot, code: mod}
+}
+
+// Chord maps a set of keys which are pressed at (nearly) the same time,
+// onto an action. The action may not be a tap-hold.
+type Chord struct {
+	Keys   []int
+	Action Action
+}
+
+// KeyProcessor turns raw key presses into a resolved key stream,
+// through layers, dual-role (tap-hold) keys, one-shot modifiers and
+// chords. It is a Filter; events other than key events pass through
+// unchanged.
+//
+// Decisions are made based on the event timestamps. Key events which
+// can not be decided on yet (e.g.: a tap-hold key which is still held,
+// but not for long enough) are held back, along with all the key events
+// which follow them, until the decision can be made.
+//
+// The configuration must not be changed once processing has started.
+type KeyProcessor struct {
+	// Layers maps keys onto their actions, per layer. Layer 0 is the
+	// base layer; keys which are not in it, produce themselves. Higher
+	// layers take precedence when active.
+	Layers []map[int]Action
+
+	// Chords lists the chords to recognize.
+	Chords []Chord
+
+	// TapTimeout is the time after which a tap-hold key counts as held.
+	// It defaults to DefaultTapTimeout.
+	TapTimeout time.Duration
+
+	// ChordTimeout is the time in which all keys of a chord must be
+	// pressed. It defaults to DefaultChordTimeout.
+	ChordTimeout time.Duration
+
+	// OneShotTimeout is the time after which a tapped one-shot modifier
+	// is cancelled, if no key has been pressed. Zero means never.
+	OneShotTimeout time.Duration
+
+	// PermissiveHold makes a tap-hold key count as held, when another
+	// key is pressed and released while it is down; even if this
+	// happens before TapTimeout. This favours the hold action when
+	// typing quickly, e.g. for Shift+A.
+	PermissiveHold bool
+
+	queue    []Event            // Key events awaiting a decision.
+	active   map[int]*activeKey // Keys which are down, by code.
+	layers   []int              // Activation count per layer.
+	armed    []int              // Tapped one-shot modifiers.
+	armedAt  time.Duration      // Time the last one-shot was armed.
+	now      time.Duration      // Time of the most recent event.
+	out      []Event            // Output being collected.
+	shutdown bool               // Input has ended; decide everything.
+}
+
+// activeKey describes a key which is down, along with what it does.
+type activeKey struct {
+	action   Action
+	used     bool // One-shot modifier: another key was pressed while down.
+	chord    bool // Shared by the keys of a chord.
+	released bool // Chord: the action has been released.
+}
+
+// NewKeyProcessor creates a processor with the given layers.
+func NewKeyProcessor(layers ...map[int]Action) *KeyProcessor {
+	return &KeyProcessor{Layers: layers}
+}
+
+// Feed passes a single event to the processor and returns
+// the events it has resolved to, if any.
+func (p *KeyProcessor) Feed(e Event) []Event {
+	if e.Type != EvKeys {
+		return []Event{e}
+	}
+
+	p.init()
+
+	if t := eventTime(e); t > p.now {
+		p.now = t
+	}
+
+	if e.Value > 1 {
+		// Repeats are only meaningful for keys which produce a key.
+		if h := p.active[int(e.Code)]; h != nil && len(p.queue) == 0 && h.action.kind == actKey && !h.chord {
+			p.emit(e, h.action.code, e.Value)
+		}
+
+		return p.flush()
+	}
+
+	p.queue = append(p.queue, e)
+	p.process()
+	return p.flush()
+}
+
+// Tick tells the processor the given time has come, without any new
+// events having arrived. It returns the events resolved because of
+// timeouts which expired. The time is in the same clock as the event
+// timestamps. See Deadline.
+func (p *KeyProcessor) Tick(now time.Duration) []Event {
+	p.init()
+
+	if now > p.now {
+		p.now = now
+	}
+
+	p.process()
+	return p.flush()
+}
+
+// Deadline returns the time at which the next timeout expires, if any.
+// Tick should be called at that time.
+func (p *KeyProcessor) Deadline() (time.Duration, bool) {
+	var list []time.Duration
+
+	if len(p.queue) > 0 {
+		e := p.queue[0]
+		t := eventTime(e)
+
+		if p.inChord(int(e.Code)) {
+			list = append(list, t+p.chordTimeout())
+		}
+
+		list = append(list, t+p.tapTimeout())
+	}
+
+	if len(p.armed) > 0 && p.OneShotTimeout > 0 {
+		list = append(list, p.armedAt+p.OneShotTimeout)
+	}
+
+	next := time.Duration(math.MaxInt64)
+	for _, t := range list {
+		if t > p.now && t < next {
+			next = t
+		}
+	}
+
+	return next, next != math.MaxInt64
+}
+
+// Run implements Filter. Timeouts are measured against the time
+// at which the most recent event was received.
+func (p *KeyProcessor) Run(in <-chan Frame, out chan<- Frame) {
+	runTimed(p, in, out, func(now time.Duration) []Event {
+		p.shutdown = true
+		return p.Tick(now)
+	})
+}
+
+// init sets up the internal state on first use.
+func (p *KeyProcessor) init() {
+	if p.active == nil {
+		p.active = make(map[int]*activeKey)
+		p.layers = make([]int, len(p.Layers))
+	}
+}
+
+// process resolves as many of the queued events as possible.
+func (p *KeyProcessor) process() {
+	for len(p.queue) > 0 {
+		e := p.queue[0]
+		code := int(e.Code)
+
+		if e.Value == 0 {
+			p.release(e)
+			p.queue = p.queue[1:]
+			continue
+		}
+
+		n, wait := p.chord()
+		if wait {
+			break
+		}
+
+		if n > 0 {
+			p.queue = p.queue[n:]
+			continue
+		}
+
+		act := p.lookup(code)
+
+		if act.kind == actTapHold {
+			tap, hold := p.decide()
+			if !tap && !hold {
+				break
+			}
+
+			if tap {
+				act = ActKey(act.code)
+			} else {
+				act = *act.hold
+			}
+		}
+
+		p.press(e, code, act, nil)
+		p.queue = p.queue[1:]
+	}
+
+	if len(p.armed) > 0 && p.OneShotTimeout > 0 && p.now-p.armedAt >= p.OneShotTimeout {
+		p.releaseArmed(timeEvent(p.now))
+	}
+}
+
+// decide determines whether the tap-hold key at the front of
+// the queue is tapped or held. It returns false for both if
+// that can not be determined yet.
+func (p *KeyProcessor) decide() (tap, hold bool) {
+	e := p.queue[0]
+	deadline := eventTime(e) + p.tapTimeout()
+	pressed := make(map[uint16]bool)
+
+	for _, x := range p.queue[1:] {
+		if eventTime(x) >= deadline {
+			return false, true
+		}
+
+		switch {
+		case x.Code == e.Code && x.Value == 0:
+			return true, false
+
+		case x.Value == 1:
+			pressed[x.Code] = true
+
+		case x.Value == 0 && pressed[x.Code] && p.PermissiveHold:
+			return false, true
+		}
+	}
+
+	if p.now >= deadline || p.shutdown {
+		return false, true
+	}
+
+	return false, false
+}
+
+// chord checks whether the keys at the front of the queue form a chord.
+// It returns the number of events consumed by the chord, or true if it
+// can not be determined yet.
+func (p *KeyProcessor) chord() (int, bool) {
+	if len(p.Chords) == 0 || !p.inChord(int(p.queue[0].Code)) {
+		return 0, false
+	}
+
+	t0 := eventTime(p.queue[0])
+	deadline := t0 + p.chordTimeout()
+	final := p.now >= deadline || p.shutdown
+
+	var keys []int
+	for _, x := range p.queue {
+		if x.Value != 1 || eventTime(x) >= deadline || !p.inChord(int(x.Code)) || hasKey(keys, int(x.Code)) {
+			final = true
+			break
+		}
+
+		keys = append(keys, int(x.Code))
+	}
+
+	if !final {
+		for _, c := range p.Chords {
+			if len(c.Keys) > len(keys) && containsKeys(c.Keys, keys) {
+				return 0, true
+			}
+		}
+	}
+
+	// Find the longest chord formed by the first keys pressed.
+	for n := len(keys); n > 1; n-- {
+		for _, c := range p.Chords {
+			if len(c.Keys) != n || !containsKeys(c.Keys, keys[:n]) {
+				continue
+			}
+
+			h := &activeKey{chord: true}
+			for i, code := range keys[:n] {
+				if i == 0 {
+					p.press(p.queue[0], code, c.Action, h)
+				} else {
+					p.active[code] = h
+				}
+			}
+
+			return n, false
+		}
+	}
+
+	return 0, false
+}
+
+// press performs the given action for a key which has been pressed.
+// If h is not nil, it is used to track the key.
+func (p *KeyProcessor) press(e Event, code int, act Action, h *activeKey) {
+	if h == nil {
+		h = new(activeKey)
+	}
+
+	h.action = act
+	p.active[code] = h
+
+	switch act.kind {
+	case actKey:
+		p.emit(e, act.code, 1)
+
+		for _, o := range p.active {
+			if o.action.kind == actOneShot {
+				o.used = true
+			}
+		}
+
+		p.releaseArmed(e)
+
+	case actLayer:
+		if act.code < len(p.layers) {
+			p.layers[act.code]++
+		}
+
+	case actOneShot:
+		if !hasKey(p.armed, act.code) {
+			p.emit(e, act.code, 1)
+		} else {
+			p.armed = removeKey(p.armed, act.code)
+		}
+	}
+}
+
+// release undoes the action of a key which has been released.
+func (p *KeyProcessor) release(e Event) {
+	code := int(e.Code)
+	h := p.active[code]
+
+	if h == nil {
+		// Pressed before we started; pass it on.
+		p.emit(e, code, 0)
+		return
+	}
+
+	delete(p.active, code)
+
+	if h.chord {
+		if h.released {
+			return
+		}
+		h.released = true
+	}
+
+	switch act := h.action; act.kind {
+	case actKey:
+		p.emit(e, act.code, 0)
+
+	case actLayer:
+		if act.code < len(p.layers) {
+			p.layers[act.code]--
+		}
+
+	case actOneShot:
+		if h.used {
+			p.emit(e, act.code, 0)
+		} else {
+			p.armed = append(p.armed, act.code)
+			p.armedAt = eventTime(e)
+		}
+	}
+}
+
+// releaseArmed releases all the tapped one-shot modifiers.
+func (p *KeyProcessor) releaseArmed(e Event) {
+	for _, code := range p.armed {
+		p.emit(e, code, 0)
+	}
+
+	p.armed = p.armed[:0]
+}
+
+// lookup returns the action of the given key,
+// according to the active layers.
+func (p *KeyProcessor) lookup(code int) Action {
+	for l := len(p.Layers) - 1; l >= 0; l-- {
+		if l > 0 && p.layers[l] <= 0 {
+			continue
+		}
+
+		if act, ok := p.Layers[l][code]; ok && act.kind != actTransparent {
+			return act
+		}
+	}
+
+	return ActKey(code)
+}
+
+// inChord returns true if the given key is part of a chord.
+func (p *KeyProcessor) inChord(code int) bool {
+	for _, c := range p.Chords {
+		if hasKey(c.Keys, code) {
+			return true
+		}
+	}
+	return false
+}
+
+// emit adds a key event to the output, with the timestamp of e.
+func (p *KeyProcessor) emit(e Event, code int, value int32) {
+	p.out = append(p.out, Event{Time: e.Time, Type: EvKeys, Code: uint16(code), Value: value})
+}
+
+// flush returns the output collected so far.
+func (p *KeyProcessor) flush() []Event {
+	out := p.out
+	p.out = nil
+	return out
+}
+
+func (p *KeyProcessor) tapTimeout() time.Duration {
+	if p.TapTimeout > 0 {
+		return p.TapTimeout
+	}
+	return DefaultTapTimeout
+}
+
+func (p *KeyProcessor) chordTimeout() time.Duration {
+	if p.ChordTimeout > 0 {
+		return p.ChordTimeout
+	}
+	return DefaultChordTimeout
+}
+
+// eventTime returns the timestamp of the given event.
+func eventTime(e Event) time.Duration {
+	return time.Duration(e.Time.Nano())
+}
+
+// timeEvent returns an empty event with the given timestamp.
+func timeEvent(t time.Duration) Event {
+	return Event{Time: syscall.NsecToTimeval(int64(t))}
+}
+
+// hasKey returns true if list contains code.
+func hasKey(list []int, code int) bool {
+	for _, v := range list {
+		if v == code {
+			return true
+		}
+	}
+	return false
+}
+
+// containsKeys returns true if set contains all of keys.
+func containsKeys(set, keys []int) bool {
+	for _, code := range keys {
+		if !hasKey(set, code) {
+			return false
+		}
+	}
+	return true
+}
+
+// removeKey returns list without code.
+func removeKey(list []int, code int) []int {
+	out := list[:0]
+	for _, v := range list {
+		if v != code {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,129 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import (
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestKeyProcessor(t *testing.T) {
+	spaceShift := map[int]Action{
+		KeySpace:    ActTapHold(KeySpace, ActKey(KeyLeftShift)),
+		KeyCapsLock: ActLayer(1),
+		KeyRightAlt: ActOneShot(KeyRightAlt),
+		KeyInsert:   ActNone,
+	}
+
+	nav := map[int]Action{
+		KeyH: ActKey(KeyLeft),
+		KeyL: ActKey(KeyRight),
+	}
+
+	tests := []struct {
+		name       string
+		permissive bool
+		events     []Event
+		tick       int // Milliseconds; 0 means no tick.
+		want       []Event
+	}{
+		{
+			name:   "tap",
+			events: []Event{eventAt(EvKeys, KeySpace, 0, 1), eventAt(EvKeys, KeySpace, 100, 0)},
+			want:   []Event{key(KeySpace, 1), key(KeySpace, 0)},
+		},
+		{
+			name:   "hold by timeout",
+			events: []Event{eventAt(EvKeys, KeySpace, 0, 1)},
+			tick:   200,
+			want:   []Event{key(KeyLeftShift, 1)},
+		},
+		{
+			name:   "hold by later event",
+			events: []Event{eventAt(EvKeys, KeySpace, 0, 1), eventAt(EvKeys, KeyA, 250, 1), eventAt(EvKeys, KeyA, 260, 0), eventAt(EvKeys, KeySpace, 270, 0)},
+			want:   []Event{key(KeyLeftShift, 1), key(KeyA, 1), key(KeyA, 0), key(KeyLeftShift, 0)},
+		},
+		{
+			name:   "rolling tap",
+			events: []Event{eventAt(EvKeys, KeySpace, 0, 1), eventAt(EvKeys, KeyA, 50, 1), eventAt(EvKeys, KeyA, 80, 0), eventAt(EvKeys, KeySpace, 120, 0)},
+			want:   []Event{key(KeySpace, 1), key(KeyA, 1), key(KeyA, 0), key(KeySpace, 0)},
+		},
+		{
+			name:       "permissive hold",
+			permissive: true,
+			events:     []Event{eventAt(EvKeys, KeySpace, 0, 1), eventAt(EvKeys, KeyA, 50, 1), eventAt(EvKeys, KeyA, 80, 0), eventAt(EvKeys, KeySpace, 120, 0)},
+			want:       []Event{key(KeyLeftShift, 1), key(KeyA, 1), key(KeyA, 0), key(KeyLeftShift, 0)},
+		},
+		{
+			name: "layer",
+			events: []Event{
+				eventAt(EvKeys, KeyCapsLock, 0, 1), eventAt(EvKeys, KeyH, 10, 1), eventAt(EvKeys, KeyCapsLock, 20, 0),
+				eventAt(EvKeys, KeyH, 30, 0), eventAt(EvKeys, KeyJ, 40, 1), eventAt(EvKeys, KeyL, 50, 1),
+			},
+			want: []Event{key(KeyLeft, 1), key(KeyLeft, 0), key(KeyJ, 1), key(KeyL, 1)},
+		},
+		{
+			name:   "one-shot",
+			events: []Event{eventAt(EvKeys, KeyRightAlt, 0, 1), eventAt(EvKeys, KeyRightAlt, 10, 0), eventAt(EvKeys, KeyE, 20, 1), eventAt(EvKeys, KeyE, 30, 0), eventAt(EvKeys, KeyE, 40, 1)},
+			want:   []Event{key(KeyRightAlt, 1), key(KeyE, 1), key(KeyRightAlt, 0), key(KeyE, 0), key(KeyE, 1)},
+		},
+		{
+			name:   "one-shot held",
+			events: []Event{eventAt(EvKeys, KeyRightAlt, 0, 1), eventAt(EvKeys, KeyE, 10, 1), eventAt(EvKeys, KeyE, 20, 0), eventAt(EvKeys, KeyRightAlt, 30, 0)},
+			want:   []Event{key(KeyRightAlt, 1), key(KeyE, 1), key(KeyE, 0), key(KeyRightAlt, 0)},
+		},
+		{
+			name:   "disabled",
+			events: []Event{eventAt(EvKeys, KeyInsert, 0, 1), eventAt(EvKeys, KeyInsert, 10, 0)},
+			want:   nil,
+		},
+		{
+			name:   "chord",
+			events: []Event{eventAt(EvKeys, KeyJ, 0, 1), eventAt(EvKeys, KeyK, 20, 1), eventAt(EvKeys, KeyJ, 100, 0), eventAt(EvKeys, KeyK, 110, 0)},
+			want:   []Event{key(KeyEscape, 1), key(KeyEscape, 0)},
+		},
+		{
+			name:   "no chord",
+			events: []Event{eventAt(EvKeys, KeyJ, 0, 1), eventAt(EvKeys, KeyK, 80, 1)},
+			tick:   130,
+			want:   []Event{key(KeyJ, 1), key(KeyK, 1)},
+		},
+		{
+			name:   "chord pending",
+			events: []Event{eventAt(EvKeys, KeyJ, 0, 1)},
+			tick:   50,
+			want:   []Event{key(KeyJ, 1)},
+		},
+	}
+
+	for _, tt := range tests {
+		p := NewKeyProcessor(spaceShift, nav)
+		p.PermissiveHold = tt.permissive
+		p.Chords = []Chord{{Keys: []int{KeyJ, KeyK}, Action: ActKey(KeyEscape)}}
+
+		var got []Event
+		for _, e := range tt.events {
+			got = append(got, p.Feed(e)...)
+		}
+
+		if tt.tick > 0 {
+			if d, ok := p.Deadline(); !ok || d != time.Duration(tt.tick)*time.Millisecond {
+				t.Fatalf("%s: Want deadline %dms, have %v %v", tt.name, tt.tick, d, ok)
+			}
+
+			got = append(got, p.Tick(time.Duration(tt.tick)*time.Millisecond)...)
+		}
+
+		// Only the codes and values are compared.
+		for i := range got {
+			got[i].Time = syscall.Timeval{}
+		}
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("%s: Want %v, have %v", tt.name, tt.want, got)
+		}
+	}
+}
@@ -30,7 +30,7 @@ type KeymapEntry struct {
 func (d *Device) KeyState() Bitset {
 	bs := NewBitset(KeyMax)
 	buf := bs.Bytes()
-	ioctl(d.fd.Fd(), _EVIOCGKEY(len(buf)), unsafe.Pointer(&buf[0]))
+	d.ioctl(_EVIOCGKEY(len(buf)), unsafe.Pointer(&buf[0]))
 	return bs
 }
 
@@ -46,7 +46,7 @@ func (d *Device) KeyState() Bitset {
 func (d *Device) KeyMap(keycode int) KeymapEntry {
 	var entry KeymapEntry
 	entry.Keycode = uint32(keycode)
-	ioctl(d.fd.Fd(), _EVIOCGKEYCODE, unsafe.Pointer(&entry))
+	d.ioctl(_EVIOCGKEYCODE, unsafe.Pointer(&entry))
 	return entry
 }
 
@@ -67,7 +67,7 @@ func (d *Device) KeyMap(keycode int) KeymapEntry {
 // Be aware that the KeyMap functions may not work on every keyboard.
 // This is only applicable to devices with EvKey event support.
 func (d *Device) SetKeyMap(entry KeymapEntry) bool {
-	return ioctl(d.fd.Fd(), _EVIOCSKEYCODE, unsafe.Pointer(&entry)) == nil
+	return d.ioctl(_EVIOCSKEYCODE, unsafe.Pointer(&entry)) == nil
 }
 
 /* Keys and buttons
@@ -558,6 +558,10 @@ const (
 	BtnC              = 0x132
 	BtnX              = 0x133
 	BtnY              = 0x134
+	BtnSouth          = 0x130 // Alias for BtnA
+	BtnEast           = 0x131 // Alias for BtnB
+	BtnNorth          = 0x133 // Alias for BtnX
+	BtnWest           = 0x134 // Alias for BtnY
 	BtnZ              = 0x135
 	BtnTL             = 0x136
 	BtnTR             = 0x137
@@ -587,6 +591,10 @@ const (
 	BtnWheel          = 0x150
 	BtnGearDown       = 0x150
 	BtnGearUp         = 0x151
+	BtnDpadUp         = 0x220
+	BtnDpadDown       = 0x221
+	BtnDpadLeft       = 0x222
+	BtnDpadRight      = 0x223
 	BtnTriggerHappy   = 0x2c0
 	BtnTriggerHappy1  = 0x2c0
 	BtnTriggerHappy2  = 0x2c1
@@ -0,0 +1,207 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import "fmt"
+
+// Modifiers which must be held for a KeyStroke.
+const (
+	ModShift = 1 << iota // Either of the shift keys.
+	ModAltGr             // The right alt key, a.k.a. AltGr.
+)
+
+// KeyStroke describes a single key press, along with the
+// modifiers which must be held down while pressing it.
+type KeyStroke struct {
+	Code int // Key code; KeyXXX.
+	Mods int // Modifiers; ModXXX.
+}
+
+// KeyboardLayout maps characters onto the keystrokes which
+// produce them on a keyboard with a given layout.
+//
+// Characters which have no key of their own, may be typed through
+// a dead key: e.g. on a German layout, 'ê' is typed by pressing '^'
+// (which produces no output by itself) followed by 'e'.
+type KeyboardLayout struct {
+	Name string
+	keys map[rune]KeyStroke
+	dead map[rune]KeyStroke
+}
+
+// Predefined keyboard layouts.
+var (
+	LayoutUS = newLayoutUS() // US English (QWERTY)
+	LayoutUK = newLayoutUK() // British English (QWERTY)
+	LayoutDE = newLayoutDE() // German (QWERTZ), with dead keys.
+	LayoutFR = newLayoutFR() // French (AZERTY), with dead keys.
+)
+
+// Strokes returns the keystrokes needed to type the given character.
+// This is usually a single stroke, but may be more when dead keys
+// are involved. It returns an error if the character can not be
+// typed with this layout.
+func (l *KeyboardLayout) Strokes(r rune) ([]KeyStroke, error) {
+	if ks, ok := l.keys[r]; ok {
+		return []KeyStroke{ks}, nil
+	}
+
+	// A dead key by itself, is typed by following it up with a space.
+	if ks, ok := l.dead[r]; ok {
+		return []KeyStroke{ks, {Code: KeySpace}}, nil
+	}
+
+	for accent, dk := range l.dead {
+		base, ok := deadBase(accent, r)
+		if !ok {
+			continue
+		}
+
+		if ks, ok := l.keys[base]; ok {
+			return []KeyStroke{dk, ks}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("layout %s: can not type %q", l.Name, r)
+}
+
+// newLayout creates a layout holding the keys which
+// are the same for every layout.
+func newLayout(name string) *KeyboardLayout {
+	l := &KeyboardLayout{
+		Name: name,
+		keys: make(map[rune]KeyStroke),
+		dead: make(map[rune]KeyStroke),
+	}
+
+	l.keys[' '] = KeyStroke{Code: KeySpace}
+	l.keys['\n'] = KeyStroke{Code: KeyEnter}
+	l.keys['\t'] = KeyStroke{Code: KeyTab}
+	l.keys['\b'] = KeyStroke{Code: KeyBackSpace}
+	return l
+}
+
+// Key rows of a standard ISO keyboard, from top to bottom.
+// Row B starts with the extra key left of Z.
+var (
+	rowE = []int{KeyGrave, Key1, Key2, Key3, Key4, Key5, Key6, Key7, Key8, Key9, Key0, KeyMinus, KeyEqual}
+	rowD = []int{KeyQ, KeyW, KeyE, KeyR, KeyT, KeyY, KeyU, KeyI, KeyO, KeyP, KeyLeftBrace, KeyRightBrace}
+	rowC = []int{KeyA, KeyS, KeyD, KeyF, KeyG, KeyH, KeyJ, KeyK, KeyL, KeySemiColon, KeyApostrophe, KeyBackSlash}
+	rowB = []int{Key102ND, KeyZ, KeyX, KeyC, KeyV, KeyB, KeyN, KeyM, KeyComma, KeyDot, KeySlash}
+)
+
+// row maps the characters in chars onto the key codes in the same
+// position in codes. A space means the key yields nothing useful
+// for the given modifiers. Existing mappings are left alone, so the
+// simplest way to type a character is the one defined first.
+func (l *KeyboardLayout) row(mods int, codes []int, chars string) {
+	list := []rune(chars)
+	if len(list) != len(codes) {
+		panic(fmt.Sprintf("layout %s: row %q has %d keys, want %d", l.Name, chars, len(list), len(codes)))
+	}
+
+	for i, r := range list {
+		if _, ok := l.keys[r]; ok || r == ' ' {
+			continue
+		}
+
+		l.keys[r] = KeyStroke{Code: codes[i], Mods: mods}
+	}
+}
+
+// deadKey defines the keystroke for the given dead key.
+func (l *KeyboardLayout) deadKey(accent rune, code, mods int) {
+	l.dead[accent] = KeyStroke{Code: code, Mods: mods}
+}
+
+// deadKeys lists, for each dead key, the characters it combines with,
+// followed by the result.
+var deadKeys = map[rune]string{
+	'^': "aâeêiîoôuûAÂEÊIÎOÔUÛ",
+	'´': "aáeéiíoóuúyýAÁEÉIÍOÓUÚYÝ",
+	'`': "aàeèiìoòuùAÀEÈIÌOÒUÙ",
+	'¨': "aäeëiïoöuüyÿAÄEËIÏOÖUÜ",
+	'~': "aãnñoõAÃNÑOÕ",
+}
+
+// deadBase returns the character which, typed after the given dead key,
+// yields r.
+func deadBase(accent, r rune) (rune, bool) {
+	list := []rune(deadKeys[accent])
+
+	for i := 1; i < len(list); i += 2 {
+		if list[i] == r {
+			return list[i-1], true
+		}
+	}
+
+	return 0, false
+}
+
+func newLayoutUS() *KeyboardLayout {
+	l := newLayout("us")
+	l.row(0, rowE, "`1234567890-=")
+	l.row(ModShift, rowE, "~!@#$%^&*()_+")
+	l.row(0, rowD, "qwertyuiop[]")
+	l.row(ModShift, rowD, "QWERTYUIOP{}")
+	l.row(0, rowC, "asdfghjkl;'\\")
+	l.row(ModShift, rowC, "ASDFGHJKL:\"|")
+	l.row(0, rowB, " zxcvbnm,./")
+	l.row(ModShift, rowB, " ZXCVBNM<>?")
+	return l
+}
+
+func newLayoutUK() *KeyboardLayout {
+	l := newLayout("gb")
+	l.row(0, rowE, "`1234567890-=")
+	l.row(ModShift, rowE, "¬!\"£$%^&*()_+")
+	l.row(ModAltGr, rowE, "¦   €        ")
+	l.row(0, rowD, "qwertyuiop[]")
+	l.row(ModShift, rowD, "QWERTYUIOP{}")
+	l.row(ModAltGr, rowD, "  é   úíó   ")
+	l.row(ModAltGr|ModShift, rowD, "  É   ÚÍÓ   ")
+	l.row(0, rowC, "asdfghjkl;'#")
+	l.row(ModShift, rowC, "ASDFGHJKL:@~")
+	l.row(ModAltGr, rowC, "á           ")
+	l.row(ModAltGr|ModShift, rowC, "Á           ")
+	l.row(0, rowB, "\\zxcvbnm,./")
+	l.row(ModShift, rowB, "|ZXCVBNM<>?")
+	return l
+}
+
+func newLayoutDE() *KeyboardLayout {
+	l := newLayout("de")
+	l.row(0, rowE, " 1234567890ß ")
+	l.row(ModShift, rowE, "°!\"§$%&/()=? ")
+	l.row(ModAltGr, rowE, " ¹²³¼½¬{[]}\\ ")
+	l.row(0, rowD, "qwertzuiopü+")
+	l.row(ModShift, rowD, "QWERTZUIOPÜ*")
+	l.row(ModAltGr, rowD, "@ €        ~")
+	l.row(0, rowC, "asdfghjklöä#")
+	l.row(ModShift, rowC, "ASDFGHJKLÖÄ'")
+	l.row(0, rowB, "<yxcvbnm,.-")
+	l.row(ModShift, rowB, ">YXCVBNM;:_")
+	l.row(ModAltGr, rowB, "|      µ   ")
+	l.deadKey('^', KeyGrave, 0)
+	l.deadKey('´', KeyEqual, 0)
+	l.deadKey('`', KeyEqual, ModShift)
+	return l
+}
+
+func newLayoutFR() *KeyboardLayout {
+	l := newLayout("fr")
+	l.row(0, rowE, "²&é\"'(-è_çà)=")
+	l.row(ModShift, rowE, " 1234567890°+")
+	l.row(ModAltGr, rowE, "  ~#{[|`\\^@]}")
+	l.row(0, rowD, "azertyuiop $")
+	l.row(ModShift, rowD, "AZERTYUIOP £")
+	l.row(ModAltGr, rowD, "  €        ¤")
+	l.row(0, rowC, "qsdfghjklmù*")
+	l.row(ModShift, rowC, "QSDFGHJKLM%µ")
+	l.row(0, rowB, "<wxcvbn,;:!")
+	l.row(ModShift, rowB, ">WXCVBN?./§")
+	l.deadKey('^', KeyLeftBrace, 0)
+	l.deadKey('¨', KeyLeftBrace, ModShift)
+	return l
+}
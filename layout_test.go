@@ -0,0 +1,52 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLayoutStrokes(t *testing.T) {
+	want := []struct {
+		Layout  *KeyboardLayout
+		Rune    rune
+		Strokes []KeyStroke
+	}{
+		{LayoutUS, 'a', []KeyStroke{{KeyA, 0}}},
+		{LayoutUS, 'A', []KeyStroke{{KeyA, ModShift}}},
+		{LayoutUS, '@', []KeyStroke{{Key2, ModShift}}},
+		{LayoutUS, '\n', []KeyStroke{{KeyEnter, 0}}},
+		{LayoutUK, '@', []KeyStroke{{KeyApostrophe, ModShift}}},
+		{LayoutUK, '£', []KeyStroke{{Key3, ModShift}}},
+		{LayoutUK, '\\', []KeyStroke{{Key102ND, 0}}},
+		{LayoutDE, 'z', []KeyStroke{{KeyY, 0}}},
+		{LayoutDE, 'ö', []KeyStroke{{KeySemiColon, 0}}},
+		{LayoutDE, '@', []KeyStroke{{KeyQ, ModAltGr}}},
+		{LayoutDE, 'ê', []KeyStroke{{KeyGrave, 0}, {KeyE, 0}}},
+		{LayoutDE, 'È', []KeyStroke{{KeyEqual, ModShift}, {KeyE, ModShift}}},
+		{LayoutDE, '^', []KeyStroke{{KeyGrave, 0}, {KeySpace, 0}}},
+		{LayoutFR, 'a', []KeyStroke{{KeyQ, 0}}},
+		{LayoutFR, '1', []KeyStroke{{Key1, ModShift}}},
+		{LayoutFR, 'é', []KeyStroke{{Key2, 0}}},
+		{LayoutFR, 'ö', []KeyStroke{{KeyLeftBrace, ModShift}, {KeyO, 0}}},
+		{LayoutFR, '!', []KeyStroke{{KeySlash, 0}}},
+	}
+
+	for i, w := range want {
+		have, err := w.Layout.Strokes(w.Rune)
+		if err != nil {
+			t.Fatalf("Index %d: %v", i, err)
+		}
+
+		if !reflect.DeepEqual(have, w.Strokes) {
+			t.Fatalf("Index %d: %s %q: Want %v, have %v",
+				i, w.Layout.Name, w.Rune, w.Strokes, have)
+		}
+	}
+
+	if _, err := LayoutUS.Strokes('ö'); err == nil {
+		t.Fatalf("Expected error for unmappable character")
+	}
+}
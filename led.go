@@ -29,6 +29,6 @@ const (
 func (d *Device) LEDState() Bitset {
 	bs := NewBitset(LedMax)
 	buf := bs.Bytes()
-	ioctl(d.fd.Fd(), _EVIOCGLED(len(buf)), unsafe.Pointer(&buf[0]))
+	d.ioctl(_EVIOCGLED(len(buf)), unsafe.Pointer(&buf[0]))
 	return bs
 }
@@ -0,0 +1,392 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MacroStep is a single frame of a macro, along with the time
+// which passed since the previous one.
+type MacroStep struct {
+	Delay time.Duration
+	Frame Frame
+}
+
+// Macro is a recorded sequence of frames.
+//
+// Macros are stored in a line based text format. Every line holds a
+// single frame; its events are separated by semicolons. Events are
+// written as a code name (see CodeName), followed by the value. Keys
+// use "down", "up" and "repeat" for their values. Pauses between frames
+// are written on lines of their own, as "wait" followed by a duration.
+// A pause at the end is kept as a final step without events; it delays
+// the next loop, or the end of the playback. Lines starting with '#'
+// are ignored. E.g.:
+//
+//	# Copy the selection.
+//	KeyLeftCtrl down
+//	wait 15ms
+//	KeyC down
+//	wait 80ms
+//	KeyC up; KeyLeftCtrl up
+//	RelX 5; RelY -2
+type Macro []MacroStep
+
+// MacroRecorder is a Sink which records the frames written to it.
+// Only key, relative and absolute axis events are recorded. Key
+// repeats are left out, as the device they are played back on
+// generates its own.
+type MacroRecorder struct {
+	Macro Macro
+	last  time.Duration
+}
+
+// WriteFrame implements Sink.
+func (r *MacroRecorder) WriteFrame(f Frame) error {
+	var out Frame
+
+	for _, e := range f {
+		switch {
+		case e.Type == EvKeys && e.Value > 1:
+		case e.Type == EvKeys, e.Type == EvRelative, e.Type == EvAbsolute:
+			out = append(out, e)
+		}
+	}
+
+	if len(out) == 0 {
+		return nil
+	}
+
+	t := eventTime(out[0])
+
+	var delay time.Duration
+	if len(r.Macro) > 0 && t > r.last {
+		delay = t - r.last
+	}
+
+	r.last = t
+	r.Macro = append(r.Macro, MacroStep{Delay: delay, Frame: out})
+	return nil
+}
+
+// RecordMacro records a macro from the given device, until the given
+// stop key is pressed. The stop key itself is not recorded.
+func RecordMacro(dev *Device, stop int) (Macro, error) {
+	var rec MacroRecorder
+	src := NewDeviceSource(dev)
+
+	for {
+		f, err := src.ReadFrame()
+		if err != nil {
+			return rec.Macro, err
+		}
+
+		for i, e := range f {
+			if e.Type == EvKeys && int(e.Code) == stop && e.Value == 1 {
+				return rec.Macro, rec.WriteFrame(f[:i])
+			}
+		}
+
+		rec.WriteFrame(f)
+	}
+}
+
+// PlayOptions control the playback of a macro.
+type PlayOptions struct {
+	// PreserveTiming plays the frames with the same pauses in between
+	// as when they were recorded. Otherwise, they are DefaultKeyDelay
+	// apart.
+	PreserveTiming bool
+
+	// MaxGap shortens pauses longer than this, when timing is
+	// preserved. Zero means no limit.
+	MaxGap time.Duration
+
+	// Loop is the number of times the macro is repeated after being
+	// played once. A negative value repeats it until stopped.
+	Loop int
+}
+
+// delay returns the pause to make before the given step.
+func (opt *PlayOptions) delay(s MacroStep) time.Duration {
+	if !opt.PreserveTiming {
+		return DefaultKeyDelay
+	}
+
+	if opt.MaxGap > 0 && s.Delay > opt.MaxGap {
+		return opt.MaxGap
+	}
+
+	return s.Delay
+}
+
+// Play writes the macro to the given sink (e.g. a VirtualDevice),
+// until it is done or the stop channel is closed. The stop channel
+// may be nil. Keys which the macro leaves pressed when it is stopped
+// are released.
+func (m Macro) Play(sink Sink, opt PlayOptions, stop <-chan struct{}) error {
+	held := make(map[uint16]bool)
+
+	err := m.play(opt, stop, func(f Frame) error {
+		for _, e := range f {
+			if e.Type == EvKeys {
+				held[e.Code] = e.Value != 0
+			}
+		}
+
+		return sink.WriteFrame(f)
+	})
+
+	var release Frame
+	for code, down := range held {
+		if down {
+			release = append(release, Event{Type: EvKeys, Code: code})
+		}
+	}
+
+	if len(release) > 0 {
+		if rerr := sink.WriteFrame(release); err == nil {
+			err = rerr
+		}
+	}
+
+	return err
+}
+
+// play passes the steps of the macro to fn, with the appropriate pauses.
+func (m Macro) play(opt PlayOptions, stop <-chan struct{}, fn func(Frame) error) error {
+	for n := 0; opt.Loop < 0 || n <= opt.Loop; n++ {
+		for i, s := range m {
+			if i > 0 || n > 0 {
+				d := opt.delay(s)
+				if i == 0 && d < DefaultKeyDelay {
+					d = DefaultKeyDelay
+				}
+
+				select {
+				case <-time.After(d):
+				case <-stop:
+					return nil
+				}
+			}
+
+			if len(s.Frame) == 0 {
+				continue
+			}
+
+			if err := fn(s.Frame); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Trigger returns a filter which plays the macro whenever the given
+// trigger key is pressed. The trigger key itself is swallowed. Pressing
+// it again while the macro is still playing, stops it; which is how
+// a looping macro is ended. Other frames pass through unchanged.
+func (m Macro) Trigger(code int, opt PlayOptions) Filter {
+	return &macroTrigger{macro: m, code: uint16(code), opt: opt}
+}
+
+type macroTrigger struct {
+	macro Macro
+	code  uint16
+	opt   PlayOptions
+}
+
+// Run implements Filter.
+func (t *macroTrigger) Run(in <-chan Frame, out chan<- Frame) {
+	frames := make(chan Frame)
+	done := make(chan struct{})
+	var stop chan struct{}
+
+	// halt stops the playback and passes on the frames
+	// which release the keys it left pressed.
+	halt := func() {
+		close(stop)
+		stop = nil
+
+		for {
+			select {
+			case f := <-frames:
+				out <- f
+			case <-done:
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case f, ok := <-in:
+			if !ok {
+				if stop != nil {
+					halt()
+				}
+				return
+			}
+
+			var rest Frame
+			for _, e := range f {
+				if e.Type != EvKeys || e.Code != t.code {
+					rest = append(rest, e)
+					continue
+				}
+
+				if e.Value != 1 {
+					continue
+				}
+
+				if stop != nil {
+					halt()
+					continue
+				}
+
+				stop = make(chan struct{})
+				go func(stop <-chan struct{}) {
+					t.macro.Play(ChanSink(frames), t.opt, stop)
+					done <- struct{}{}
+				}(stop)
+			}
+
+			if len(rest) > 0 {
+				out <- rest
+			}
+
+		case f := <-frames:
+			out <- f
+
+		case <-done:
+			stop = nil
+		}
+	}
+}
+
+// WriteTo writes the macro to w, in the text format.
+func (m Macro) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	var n int
+
+	for _, s := range m {
+		if d := s.Delay.Round(time.Millisecond); d > 0 {
+			k, err := fmt.Fprintf(bw, "wait %s\n", d)
+			n += k
+
+			if err != nil {
+				return int64(n), err
+			}
+		}
+
+		if len(s.Frame) == 0 {
+			continue
+		}
+
+		list := make([]string, len(s.Frame))
+		for i, e := range s.Frame {
+			list[i] = CodeName(int(e.Type), int(e.Code)) + " " + macroValue(e)
+		}
+
+		k, err := fmt.Fprintln(bw, strings.Join(list, "; "))
+		n += k
+
+		if err != nil {
+			return int64(n), err
+		}
+	}
+
+	return int64(n), bw.Flush()
+}
+
+// ReadMacro reads a macro from r, in the text format.
+func ReadMacro(r io.Reader) (Macro, error) {
+	var m Macro
+	var delay time.Duration
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if len(text) == 0 || text[0] == '#' {
+			continue
+		}
+
+		if strings.HasPrefix(text, "wait ") {
+			d, err := time.ParseDuration(strings.TrimSpace(text[5:]))
+			if err != nil {
+				return nil, fmt.Errorf("macro: line %d: %v", line, err)
+			}
+
+			delay += d
+			continue
+		}
+
+		var f Frame
+		for _, field := range strings.Split(text, ";") {
+			e, err := parseMacroEvent(strings.Fields(field))
+			if err != nil {
+				return nil, fmt.Errorf("macro: line %d: %v", line, err)
+			}
+
+			f = append(f, e)
+		}
+
+		m = append(m, MacroStep{Delay: delay, Frame: f})
+		delay = 0
+	}
+
+	if delay > 0 {
+		m = append(m, MacroStep{Delay: delay})
+	}
+
+	return m, scanner.Err()
+}
+
+// Values used for key events in the macro format.
+var macroKeyValues = []string{"up", "down", "repeat"}
+
+// macroValue formats the value of the given event.
+func macroValue(e Event) string {
+	if e.Type == EvKeys && e.Value >= 0 && int(e.Value) < len(macroKeyValues) {
+		return macroKeyValues[e.Value]
+	}
+
+	return strconv.Itoa(int(e.Value))
+}
+
+// parseMacroEvent parses a single event; a code name and a value.
+func parseMacroEvent(fields []string) (Event, error) {
+	if len(fields) != 2 {
+		return Event{}, fmt.Errorf("invalid event %q", strings.Join(fields, " "))
+	}
+
+	evtype, code, err := ParseCode(fields[0])
+	if err != nil {
+		return Event{}, err
+	}
+
+	e := Event{Type: uint16(evtype), Code: uint16(code)}
+
+	for v, name := range macroKeyValues {
+		if evtype == EvKeys && fields[1] == name {
+			e.Value = int32(v)
+			return e, nil
+		}
+	}
+
+	v, err := strconv.ParseInt(fields[1], 0, 32)
+	if err != nil {
+		return Event{}, fmt.Errorf("invalid value %q", fields[1])
+	}
+
+	e.Value = int32(v)
+	return e, nil
+}
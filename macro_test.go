@@ -0,0 +1,215 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCodeNames(t *testing.T) {
+	tests := []struct {
+		evtype, code int
+		name         string
+	}{
+		{EvKeys, KeyA, "KeyA"},
+		{EvKeys, BtnLeft, "BtnLeft"},
+		{EvKeys, BtnA, "BtnA"},
+		{EvRelative, RelWheelHiRes, "RelWheelHiRes"},
+		{EvAbsolute, AbsMTSlot, "AbsMTSlot"},
+		{EvMisc, MiscScan, "0x04:0x04"},
+	}
+
+	for _, tt := range tests {
+		if name := CodeName(tt.evtype, tt.code); name != tt.name {
+			t.Fatalf("CodeName(%d, %d): Want %q, have %q", tt.evtype, tt.code, tt.name, name)
+		}
+
+		evtype, code, err := ParseCode(tt.name)
+		if err != nil || evtype != tt.evtype || code != tt.code {
+			t.Fatalf("ParseCode(%q): Want %d, %d, <nil>, have %d, %d, %v", tt.name, tt.evtype, tt.code, evtype, code, err)
+		}
+	}
+
+	if _, code, err := ParseCode("BtnSouth"); err != nil || code != BtnA {
+		t.Fatalf("ParseCode(BtnSouth): Want %d, <nil>, have %d, %v", BtnA, code, err)
+	}
+
+	if _, _, err := ParseCode("KeyBogus"); err == nil {
+		t.Fatalf("ParseCode(KeyBogus): Expected error")
+	}
+}
+
+func TestMacroFormat(t *testing.T) {
+	text := `# Copy the selection.
+KeyLeftCtrl down
+wait 15ms
+KeyC down
+wait 80ms
+KeyC up; KeyLeftCtrl up
+RelX 5; RelY -2
+`
+
+	m, err := ReadMacro(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Macro{
+		{0, Frame{key(KeyLeftCtrl, 1)}},
+		{15 * time.Millisecond, Frame{key(KeyC, 1)}},
+		{80 * time.Millisecond, Frame{key(KeyC, 0), key(KeyLeftCtrl, 0)}},
+		{0, Frame{{Type: EvRelative, Code: RelX, Value: 5}, {Type: EvRelative, Code: RelY, Value: -2}}},
+	}
+
+	if !reflect.DeepEqual(m, want) {
+		t.Fatalf("Want %v, have %v", want, m)
+	}
+
+	var buf bytes.Buffer
+	m.WriteTo(&buf)
+
+	if want, have := text[strings.IndexByte(text, '\n')+1:], buf.String(); have != want {
+		t.Fatalf("WriteTo:\nWant %s\nhave %s", want, have)
+	}
+
+	if _, err := ReadMacro(strings.NewReader("KeyA sideways\n")); err == nil {
+		t.Fatalf("Invalid value: Expected error")
+	}
+}
+
+func TestMacroTrailingWait(t *testing.T) {
+	text := `KeyA down
+wait 40ms
+KeyA up
+wait 250ms
+`
+
+	m, err := ReadMacro(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Macro{
+		{0, Frame{key(KeyA, 1)}},
+		{40 * time.Millisecond, Frame{key(KeyA, 0)}},
+		{250 * time.Millisecond, nil},
+	}
+
+	if !reflect.DeepEqual(m, want) {
+		t.Fatalf("Want %v, have %v", want, m)
+	}
+
+	var buf bytes.Buffer
+	if _, err = m.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if have := buf.String(); have != text {
+		t.Fatalf("WriteTo:\nWant %s\nhave %s", text, have)
+	}
+
+	// The final pause plays no frame of its own.
+	var frames []Frame
+	m.play(PlayOptions{}, nil, func(f Frame) error {
+		frames = append(frames, f)
+		return nil
+	})
+
+	if len(frames) != 2 {
+		t.Fatalf("Play: Want 2 frames, have %v", frames)
+	}
+}
+
+func TestMacroWriteError(t *testing.T) {
+	m := make(Macro, 1000)
+	for i := range m {
+		m[i] = MacroStep{time.Second, Frame{key(KeyA, 1)}}
+	}
+
+	fail := errors.New("disk full")
+
+	n, err := m.WriteTo(failWriter{fail})
+	if err != fail {
+		t.Fatalf("Want %v, have %v", fail, err)
+	}
+
+	// Writing stops at the first line which does not fit in the buffer.
+	if n > 8192 {
+		t.Fatalf("Want at most 8192 bytes, have %d", n)
+	}
+}
+
+// failWriter fails every write with the given error.
+type failWriter struct {
+	err error
+}
+
+func (w failWriter) Write(p []byte) (int, error) {
+	return 0, w.err
+}
+
+func TestMacroRecorder(t *testing.T) {
+	var rec MacroRecorder
+
+	frames := []Frame{
+		{eventAt(EvKeys, KeyA, 1000, 1), {Type: EvMisc, Code: MiscScan, Value: 30}},
+		{eventAt(EvKeys, KeyA, 1500, 2)},
+		{eventAt(EvKeys, KeyA, 1600, 0)},
+	}
+
+	for _, f := range frames {
+		rec.WriteFrame(f)
+	}
+
+	if len(rec.Macro) != 2 {
+		t.Fatalf("Want 2 steps, have %d", len(rec.Macro))
+	}
+
+	if rec.Macro[0].Delay != 0 || rec.Macro[1].Delay != 600*time.Millisecond {
+		t.Fatalf("Delays: Want 0s and 600ms, have %v and %v", rec.Macro[0].Delay, rec.Macro[1].Delay)
+	}
+
+	opt := PlayOptions{PreserveTiming: true, MaxGap: 100 * time.Millisecond}
+	if d := opt.delay(rec.Macro[1]); d != 100*time.Millisecond {
+		t.Fatalf("Compressed gap: Want 100ms, have %v", d)
+	}
+}
+
+func TestMacroTrigger(t *testing.T) {
+	m := Macro{
+		{0, Frame{key(KeyA, 1)}},
+		{0, Frame{key(KeyA, 0)}},
+	}
+
+	in := make(chan Frame)
+	out := make(chan Frame)
+
+	go func() {
+		m.Trigger(KeyF12, PlayOptions{}).Run(in, out)
+		close(out)
+	}()
+
+	in <- Frame{key(KeyB, 1)}
+	got := []Frame{<-out}
+
+	in <- Frame{key(KeyF12, 1)}
+	for len(got) < 3 {
+		got = append(got, <-out)
+	}
+
+	close(in)
+	for f := range out {
+		got = append(got, f)
+	}
+
+	want := []Frame{{key(KeyB, 1)}, {key(KeyA, 1)}, {key(KeyA, 0)}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Want %v, have %v", want, got)
+	}
+}
@@ -0,0 +1,316 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import (
+	"errors"
+	"io"
+	"sort"
+	"sync"
+)
+
+// ErrNoDevices is returned by NewMerger, when it is given no devices.
+var ErrNoDevices = errors.New("evdev: no devices to merge")
+
+// MergeConfig returns the configuration for a virtual device which
+// combines the given devices: its properties and capabilities are the
+// union of theirs. Axes which several devices have in common, get a
+// range which covers all of theirs; their values are not rescaled.
+func MergeConfig(name string, devs ...*Device) VirtualConfig {
+	list := make([]VirtualConfig, len(devs))
+	for i, dev := range devs {
+		list[i] = CloneConfig(dev)
+	}
+
+	cfg := mergeConfigs(list)
+	cfg.Name = name
+	cfg.Id = Id{BusType: BusVirtual}
+	return cfg
+}
+
+// mergeConfigs returns the union of the given configurations.
+// The name and identity are left empty.
+func mergeConfigs(list []VirtualConfig) VirtualConfig {
+	cfg := VirtualConfig{
+		Capabilities: make(map[int]Bitset),
+		Absolute:     make(map[int]AbsInfo),
+	}
+
+	for _, c := range list {
+		cfg.Properties = unionBits(cfg.Properties, c.Properties)
+
+		for evtype, set := range c.Capabilities {
+			cfg.Capabilities[evtype] = unionBits(cfg.Capabilities[evtype], set)
+		}
+
+		for axis, info := range c.Absolute {
+			old, ok := cfg.Absolute[axis]
+			if ok {
+				if old.Minimum < info.Minimum {
+					info.Minimum = old.Minimum
+				}
+
+				if old.Maximum > info.Maximum {
+					info.Maximum = old.Maximum
+				}
+			}
+
+			cfg.Absolute[axis] = info
+		}
+	}
+
+	return cfg
+}
+
+// unionBits returns a bitset holding the bits set in either a or b.
+func unionBits(a, b Bitset) Bitset {
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+
+	out := make(Bitset, len(a))
+	copy(out, a)
+
+	for i, w := range b {
+		out[i] |= w
+	}
+
+	return out
+}
+
+// mergeState keeps the key state of the merged device consistent,
+// when the same key is held on more than one source. The key is
+// pressed when the first source presses it, and released when the
+// last one releases it.
+type mergeState struct {
+	count map[uint16]int    // Number of sources holding each key.
+	held  []map[uint16]bool // Keys held, per source.
+}
+
+func newMergeState(sources int) *mergeState {
+	s := &mergeState{
+		count: make(map[uint16]int),
+		held:  make([]map[uint16]bool, sources),
+	}
+
+	for i := range s.held {
+		s.held[i] = make(map[uint16]bool)
+	}
+
+	return s
+}
+
+// frame returns the frame to pass on, for a frame from the given source.
+func (s *mergeState) frame(src int, f Frame) Frame {
+	out := make(Frame, 0, len(f))
+	held := s.held[src]
+
+	for _, e := range f {
+		if e.Type != EvKeys {
+			out = append(out, e)
+			continue
+		}
+
+		switch {
+		case e.Value == 0 && held[e.Code]:
+			delete(held, e.Code)
+			if s.count[e.Code]--; s.count[e.Code] == 0 {
+				delete(s.count, e.Code)
+				out = append(out, e)
+			}
+
+		case e.Value == 1 && !held[e.Code]:
+			held[e.Code] = true
+			if s.count[e.Code]++; s.count[e.Code] == 1 {
+				out = append(out, e)
+			}
+
+		case e.Value > 1 && held[e.Code]:
+			out = append(out, e)
+		}
+	}
+
+	return out
+}
+
+// drop forgets the given source. It returns the frame which releases
+// the keys only it was holding.
+func (s *mergeState) drop(src int) Frame {
+	var out Frame
+
+	for code := range s.held[src] {
+		if s.count[code]--; s.count[code] == 0 {
+			delete(s.count, code)
+			out = append(out, Event{Type: EvKeys, Code: code})
+		}
+	}
+
+	s.held[src] = make(map[uint16]bool)
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Code < out[j].Code
+	})
+
+	return out
+}
+
+// Merger reads several devices and passes their events on through
+// a single virtual device; for applications which only accept one
+// input device. The sources are grabbed, so other applications see
+// their events only once.
+//
+// A key which is held on more than one source, is released when it
+// has been released on all of them. When a source disappears (e.g.
+// it is unplugged), the keys it was holding are released and the
+// other sources carry on.
+type Merger struct {
+	g     *grabber
+	state *mergeState
+	mu    sync.Mutex // Guards state, err and writes to the virtual device.
+	err   error      // Error writing to the virtual device; see fail.
+}
+
+// NewMerger creates a merger for the given devices, which takes
+// ownership of them; the devices are closed if this fails. The virtual
+// device gets the given name; its configuration is described by
+// MergeConfig. The devices are grabbed as by NewRemapper.
+func NewMerger(name string, devs ...*Device) (*Merger, error) {
+	if len(devs) == 0 {
+		return nil, ErrNoDevices
+	}
+
+	cfg := MergeConfig(name, devs...)
+
+	virt, err := CreateVirtual(cfg)
+	if err != nil {
+		closeDevices(devs)
+		return nil, err
+	}
+
+	if _, ok := cfg.Capabilities[EvRepeat]; ok {
+		for _, dev := range devs {
+			if dev.EventTypes().Test(EvRepeat) {
+				err = virt.SetRepeatState(dev.RepeatState())
+				break
+			}
+		}
+	}
+
+	if err != nil {
+		closeDevices(devs)
+		virt.Close()
+		return nil, err
+	}
+
+	g, err := newGrabber(virt, true, devs...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Merger{g: g, state: newMergeState(len(devs))}, nil
+}
+
+// Virtual returns the virtual device which receives the merged events.
+func (m *Merger) Virtual() *VirtualDevice {
+	return m.g.virt
+}
+
+// Run merges events until all the sources are gone, the merger is
+// closed, or writing to the virtual device fails; like Remapper.Run.
+// A source which disappears is not an error, as long as others remain.
+func (m *Merger) Run() error {
+	err := m.g.run(m.merge)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.err != nil {
+		return m.err
+	}
+
+	return err
+}
+
+// merge reads all the sources until they run out. It returns the
+// first error encountered.
+func (m *Merger) merge() error {
+	errs := make(chan error, len(m.g.devs))
+
+	for i, dev := range m.g.devs {
+		go func(i int, dev *Device) {
+			errs <- m.read(i, dev)
+		}(i, dev)
+	}
+
+	var err error
+	for range m.g.devs {
+		if e := <-errs; e != nil && err == nil {
+			err = e
+		}
+	}
+
+	return err
+}
+
+// read passes the frames of a single source on, until it runs out.
+func (m *Merger) read(i int, dev *Device) error {
+	src := NewDeviceSource(dev)
+
+	for {
+		f, err := src.ReadFrame()
+		if err != nil {
+			m.write(func() Frame { return m.state.drop(i) })
+
+			select {
+			case <-m.g.stopped:
+				return nil
+			default:
+			}
+
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		if err := m.write(func() Frame { return m.state.frame(i, f) }); err != nil {
+			m.fail(err)
+			return err
+		}
+	}
+}
+
+// fail stops the merger, after writing to the virtual device failed.
+// It interrupts the other sources, so Run returns. The error is what
+// Run returns, unless the merger was already closed.
+func (m *Merger) fail(err error) {
+	m.mu.Lock()
+	select {
+	case <-m.g.closed:
+	default:
+		m.err = err
+	}
+	m.mu.Unlock()
+
+	m.g.interrupt()
+}
+
+// write updates the key state through fn and writes the frame it
+// returns, if any.
+func (m *Merger) write(fn func() Frame) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if f := fn(); len(f) > 0 {
+		return m.g.virt.WriteFrame(f)
+	}
+
+	return nil
+}
+
+// Close stops the merger. It releases the grabs, closes the sources
+// and destroys the virtual device.
+func (m *Merger) Close() error {
+	return m.g.Close()
+}
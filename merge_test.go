@@ -0,0 +1,213 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// bits returns a bitset of the given size, with the given bits set.
+func bits(size int, list ...int) Bitset {
+	b := NewBitset(size)
+	for _, i := range list {
+		b.Set(i)
+	}
+	return b
+}
+
+func TestMergeConfigs(t *testing.T) {
+	kbd := VirtualConfig{
+		Capabilities: map[int]Bitset{
+			EvKeys:   bits(KeyCount, KeyA, KeyEnter),
+			EvLed:    bits(LedCount, LedNumLock),
+			EvRepeat: nil,
+		},
+	}
+
+	pad := VirtualConfig{
+		Properties: bits(InputPropCount, InputPropPointer),
+		Capabilities: map[int]Bitset{
+			EvKeys:     bits(KeyCount, KeyKP1, KeyEnter),
+			EvAbsolute: bits(AbsCount, AbsX),
+		},
+		Absolute: map[int]AbsInfo{AbsX: {Minimum: -10, Maximum: 100, Flat: 4}},
+	}
+
+	other := VirtualConfig{
+		Capabilities: map[int]Bitset{EvAbsolute: bits(AbsCount, AbsX)},
+		Absolute:     map[int]AbsInfo{AbsX: {Minimum: 0, Maximum: 255}},
+	}
+
+	cfg := mergeConfigs([]VirtualConfig{kbd, pad, other})
+
+	want := map[int]Bitset{
+		EvKeys:     bits(KeyCount, KeyA, KeyEnter, KeyKP1),
+		EvLed:      bits(LedCount, LedNumLock),
+		EvRepeat:   Bitset{},
+		EvAbsolute: bits(AbsCount, AbsX),
+	}
+
+	if !reflect.DeepEqual(cfg.Capabilities, want) {
+		t.Fatalf("Capabilities: Want %v, have %v", want, cfg.Capabilities)
+	}
+
+	if !cfg.Properties.Test(InputPropPointer) {
+		t.Fatalf("Properties: Want InputPropPointer, have %v", cfg.Properties)
+	}
+
+	if info := cfg.Absolute[AbsX]; info.Minimum != -10 || info.Maximum != 255 {
+		t.Fatalf("AbsX: Want range -10-255, have %+v", info)
+	}
+}
+
+func TestMergeState(t *testing.T) {
+	s := newMergeState(2)
+
+	steps := []struct {
+		src  int
+		in   Frame // nil drops the source.
+		want Frame
+	}{
+		{0, Frame{key(KeyA, 1)}, Frame{key(KeyA, 1)}},
+		{1, Frame{key(KeyA, 1), key(KeyB, 1)}, Frame{key(KeyB, 1)}},
+		{1, Frame{key(KeyA, 2)}, Frame{key(KeyA, 2)}},
+		{0, Frame{key(KeyA, 0)}, Frame{}},
+		{0, Frame{key(KeyA, 0)}, Frame{}},
+		{0, Frame{key(KeyC, 1), key(KeyA, 2)}, Frame{key(KeyC, 1)}},
+		{1, Frame{key(KeyA, 0)}, Frame{key(KeyA, 0)}},
+		{1, Frame{key(KeyA, 1), {Type: EvMisc, Code: MiscScan, Value: 4}}, Frame{key(KeyA, 1), {Type: EvMisc, Code: MiscScan, Value: 4}}},
+		{0, Frame{key(KeyA, 1)}, Frame{}},
+		{1, nil, Frame{key(KeyB, 0)}},
+		{1, Frame{key(KeyB, 1)}, Frame{key(KeyB, 1)}},
+		{0, nil, Frame{key(KeyA, 0), key(KeyC, 0)}},
+	}
+
+	for i, step := range steps {
+		var got Frame
+		if step.in == nil {
+			got = s.drop(step.src)
+		} else {
+			got = s.frame(step.src, step.in)
+		}
+
+		if len(got) == 0 && len(step.want) == 0 {
+			continue
+		}
+
+		if !reflect.DeepEqual(got, step.want) {
+			t.Fatalf("Step %d: Want %v, have %v", i, step.want, got)
+		}
+	}
+}
+
+// pipeMerger returns a merger of two pipe-backed devices, along with
+// the pipes to write their events to and to read the merged events from.
+func pipeMerger(t *testing.T) (m *Merger, in [2]*os.File, out *os.File) {
+	a, wa := pipeDevice(t)
+	b, wb := pipeDevice(t)
+
+	virt, out := pipeVirtual(t)
+
+	g, err := newGrabber(virt, false, a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &Merger{g: g, state: newMergeState(2)}, [2]*os.File{wa, wb}, out
+}
+
+// runMerger runs m and returns the channel Run's result is sent on.
+func runMerger(m *Merger) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		done <- m.Run()
+	}()
+	return done
+}
+
+// waitRun returns the result of Run, failing the test if it does not
+// return within a second.
+func waitRun(t *testing.T, done <-chan error) error {
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(time.Second):
+		t.Fatalf("Run: not stopped")
+		return nil
+	}
+}
+
+func TestMergerClose(t *testing.T) {
+	m, in, out := pipeMerger(t)
+	defer in[0].Close()
+	defer in[1].Close()
+	defer out.Close()
+
+	done := runMerger(m)
+	syn := Event{Type: EvSync, Code: SynReport}
+
+	for i, code := range []int{KeyA, KeyB} {
+		if err := writeEvents(in[i], []Event{key(code, 1), syn}); err != nil {
+			t.Fatal(err)
+		}
+
+		want := []Event{key(code, 1), syn}
+		if have := readPipe(t, out, 2); !reflect.DeepEqual(have, want) {
+			t.Fatalf("Merged: Want %v, have %v", want, have)
+		}
+	}
+
+	// Both sources are waiting for their next event; Close must stop
+	// them, and the keys they hold are released before the virtual
+	// device goes away.
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := waitRun(t, done); err != nil {
+		t.Fatalf("Run: Want <nil>, have %v", err)
+	}
+
+	released := make(map[Event]bool)
+	for _, e := range readPipe(t, out, 4) {
+		released[e] = true
+	}
+
+	want := map[Event]bool{key(KeyA, 0): true, key(KeyB, 0): true, syn: true}
+	if !reflect.DeepEqual(released, want) {
+		t.Fatalf("Released: Want %v, have %v", want, released)
+	}
+}
+
+func TestMergerWriteError(t *testing.T) {
+	m, in, out := pipeMerger(t)
+	defer in[0].Close()
+	defer in[1].Close()
+
+	// Writes to the virtual device fail once nothing reads it.
+	out.Close()
+
+	done := runMerger(m)
+
+	err := writeEvents(in[0], []Event{key(KeyA, 1), {Type: EvSync, Code: SynReport}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The other source is interrupted, so Run returns the error.
+	if err = waitRun(t, done); err == nil {
+		t.Fatalf("Run: Expected error")
+	}
+
+	m.Close()
+}
+
+func TestNewMergerNoDevices(t *testing.T) {
+	if _, err := NewMerger("merged"); err != ErrNoDevices {
+		t.Fatalf("Want %v, have %v", ErrNoDevices, err)
+	}
+}
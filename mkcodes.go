@@ -0,0 +1,190 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+//go:build ignore
+// +build ignore
+
+// This program generates codes_gen.go, which holds the names of the
+// key, button and axis codes, as used by CodeName and ParseCode.
+// Run it through `go generate`.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Range markers which alias the first code of a group. The specific
+// name of that code is more useful, so these are only used as aliases.
+var skip = map[string]bool{
+	"BtnMisc":           true,
+	"BtnMouse":          true,
+	"BtnJoystick":       true,
+	"BtnGamepad":        true,
+	"BtnDigi":           true,
+	"BtnWheel":          true,
+	"BtnTriggerHappy":   true,
+	"KeyMinInteresting": true,
+}
+
+type table struct {
+	name     string
+	prefixes []string
+	names    map[int64]string
+}
+
+// aliases holds the key names which lost out to another
+// name for the same code.
+var aliases []string
+
+func main() {
+	fset := token.NewFileSet()
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var list []*ast.File
+	for _, name := range files {
+		if name == "mkcodes.go" || name == "codes_gen.go" || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+
+		f, err := parser.ParseFile(fset, name, nil, 0)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		list = append(list, f)
+	}
+
+	// The rest of the package refers to the tables we are about to
+	// generate, so errors are expected. The constants are fine.
+	conf := types.Config{
+		Importer: importer.Default(),
+		Error:    func(error) {},
+	}
+
+	pkg, _ := conf.Check("evdev", fset, list, nil)
+
+	tables := []*table{
+		{name: "keyNames", prefixes: []string{"Key", "Btn"}},
+		{name: "relNames", prefixes: []string{"Rel"}},
+		{name: "absNames", prefixes: []string{"Abs"}},
+	}
+
+	// Walk the declarations in source order, so the first name
+	// of a code wins.
+	for _, f := range list {
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.CONST {
+				continue
+			}
+
+			for _, spec := range gd.Specs {
+				for _, id := range spec.(*ast.ValueSpec).Names {
+					add(tables, pkg, id.Name)
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by mkcodes.go; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package evdev\n\n")
+
+	for _, t := range tables {
+		codes := make([]int64, 0, len(t.names))
+		for code := range t.names {
+			codes = append(codes, code)
+		}
+		sort.Slice(codes, func(i, j int) bool { return codes[i] < codes[j] })
+
+		fmt.Fprintf(&buf, "var %s = map[int]string{\n", t.name)
+		for _, code := range codes {
+			fmt.Fprintf(&buf, "\t%s: %q,\n", t.names[code], t.names[code])
+		}
+		fmt.Fprintf(&buf, "}\n\n")
+	}
+
+	fmt.Fprintf(&buf, "var codeAliases = map[string]int{\n")
+	for _, name := range aliases {
+		fmt.Fprintf(&buf, "\t%q: %s,\n", name, name)
+	}
+	fmt.Fprintf(&buf, "}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := os.WriteFile("codes_gen.go", src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// add adds the named constant to the table it belongs to, if any.
+func add(tables []*table, pkg *types.Package, name string) {
+	if strings.HasSuffix(name, "Max") || strings.HasSuffix(name, "Count") {
+		return
+	}
+
+	c, ok := pkg.Scope().Lookup(name).(*types.Const)
+	if !ok {
+		return
+	}
+
+	for _, t := range tables {
+		for _, prefix := range t.prefixes {
+			if !strings.HasPrefix(name, prefix) || len(name) == len(prefix) {
+				continue
+			}
+
+			// Skip functions and types sharing the prefix, e.g. KeyState.
+			r := name[len(prefix)]
+			if !(r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+				continue
+			}
+
+			code, ok := constInt(c)
+			if !ok {
+				return
+			}
+
+			if t.names == nil {
+				t.names = make(map[int64]string)
+			}
+
+			if _, ok := t.names[code]; !ok && !skip[name] {
+				t.names[code] = name
+			} else if t.name == "keyNames" {
+				aliases = append(aliases, name)
+			}
+			return
+		}
+	}
+}
+
+func constInt(c *types.Const) (int64, bool) {
+	b, ok := c.Type().Underlying().(*types.Basic)
+	if !ok || b.Info()&types.IsInteger == 0 {
+		return 0, false
+	}
+
+	v := c.Val().String()
+	var n int64
+	_, err := fmt.Sscan(v, &n)
+	return n, err == nil
+}
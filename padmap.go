@@ -0,0 +1,483 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"time"
+)
+
+// ErrNoPadMapping is returned by NewPadMapper, when none
+// of the mappings it is given applies to the device.
+var ErrNoPadMapping = errors.New("evdev: no mapping for device")
+
+// Defaults for PadMapping.
+const (
+	DefaultPointerSpeed  = 1200 // Pixels per second.
+	DefaultScrollSpeed   = 15   // Notches per second.
+	DefaultTriggerPress  = 0.5
+	DefaultStickDeadzone = 0.15
+)
+
+const (
+	padHysteresis  = 0.05                   // Trigger release margin.
+	padHatPress    = 0.5                    // Hat position which counts as pushed.
+	padMaxInterval = 100 * time.Millisecond // Longest motion step.
+)
+
+// PadStick maps a stick onto pointer motion or scrolling. The speed
+// depends on how far the stick is pushed; the deadzone and curve are
+// applied to the distance from the centre.
+type PadStick struct {
+	X, Y int // The axes of the stick.
+
+	// Speed is the speed at full deflection: pixels per second for
+	// the pointer, notches per second for scrolling. It defaults to
+	// DefaultPointerSpeed and DefaultScrollSpeed, respectively.
+	Speed float64
+
+	// Deadzone is the part of the range, around the centre, which is
+	// ignored. It defaults to DefaultStickDeadzone; sticks drift.
+	Deadzone float64
+
+	// Curve shapes the response. It defaults to CurveEaseIn,
+	// for precise control at low speeds.
+	Curve Curve
+}
+
+// PadTrigger maps an analog trigger onto a key or button.
+type PadTrigger struct {
+	// Code is the key or button (KeyXXX, BtnXXX) to press.
+	Code int
+
+	// Press is the position, in [0, 1], at which the key is pressed.
+	// It defaults to DefaultTriggerPress. The key is released when the
+	// trigger is let go a little beyond this point; or halfway, for
+	// triggers which press close to their rest position.
+	Press float64
+}
+
+// PadMapping describes how a gamepad or joystick drives the pointer
+// and the keyboard.
+type PadMapping struct {
+	// Vendor and Product select the devices the mapping applies to.
+	// Zero matches any.
+	Vendor  uint16
+	Product uint16
+
+	// Pointer is the stick which moves the pointer, if not nil.
+	Pointer *PadStick
+
+	// Scroll is the stick which turns the scroll wheels, if not nil.
+	// Pushing it up scrolls up.
+	Scroll *PadStick
+
+	// Buttons maps the buttons of the pad onto keys and mouse buttons.
+	// Combinations are supported; see Mapping. Buttons which are not
+	// listed are ignored.
+	Buttons Mapping
+
+	// Hats maps the directions of hat axes (AbsHatXXX) onto keys and
+	// mouse buttons: the first for the negative direction (left, up),
+	// the second for the positive one.
+	Hats map[int][2]int
+
+	// Triggers maps analog axes onto keys and mouse buttons.
+	Triggers map[int]PadTrigger
+}
+
+// PadDesktop is a mapping for driving a desktop with an Xbox-style
+// gamepad. The left stick moves the pointer and the right one scrolls.
+// A and B are the left and right mouse buttons, X is the middle button
+// and Y is the Meta key. The d-pad produces the arrow keys; the shoulder
+// buttons go back and forward, Start is Enter and Back is Escape. The
+// triggers are the left and right mouse buttons as well.
+var PadDesktop = &PadMapping{
+	Pointer: &PadStick{X: AbsX, Y: AbsY},
+	Scroll:  &PadStick{X: AbsRX, Y: AbsRY},
+	Buttons: Mapping{
+		BtnSouth:  {BtnLeft},
+		BtnEast:   {BtnRight},
+		BtnWest:   {BtnMiddle},
+		BtnNorth:  {KeyLeftMeta},
+		BtnTL:     {BtnSide},
+		BtnTR:     {BtnExtra},
+		BtnStart:  {KeyEnter},
+		BtnSelect: {KeyEscape},
+	},
+	Hats: map[int][2]int{
+		AbsHat0X: {KeyLeft, KeyRight},
+		AbsHat0Y: {KeyUp, KeyDown},
+	},
+	Triggers: map[int]PadTrigger{
+		AbsZ:  {Code: BtnLeft},
+		AbsRZ: {Code: BtnRight},
+	},
+}
+
+// Matches returns true if the mapping applies to
+// a device with the given identity.
+func (m *PadMapping) Matches(id Id) bool {
+	return (m.Vendor == 0 || m.Vendor == id.Vendor) &&
+		(m.Product == 0 || m.Product == id.Product)
+}
+
+// keyCodes returns all the keys and buttons the mapping produces.
+func (m *PadMapping) keyCodes() []int {
+	list := m.Buttons.Codes()
+	for _, keys := range m.Hats {
+		list = append(list, keys[0], keys[1])
+	}
+
+	for _, trig := range m.Triggers {
+		list = append(list, trig.Code)
+	}
+
+	return list
+}
+
+// Filter returns a filter which applies the mapping to the events
+// of a device, with the given axes. It produces the key, button and
+// relative axis events for a device such as the one created by
+// NewPadMapper. The filter conditions the axes with a copy of the
+// given processor, set up to suit the mapping; axes is not changed.
+func (m *PadMapping) Filter(axes *AxisProcessor) Filter {
+	return newPadFilter(m, axes)
+}
+
+// padFilter implements PadMapping.Filter.
+type padFilter struct {
+	m    *PadMapping
+	axes *AxisProcessor
+
+	hats     map[int]int   // Current direction of each hat axis.
+	triggers map[int]bool  // Triggers which are pressed.
+	moving   bool          // A stick is pushed.
+	last     time.Duration // Time of the last motion frame.
+	rem      [2]float64    // Pointer motion carried over.
+	scroll   [2]float64    // Scrolling carried over, in high-resolution units.
+	wheel    int32         // Accumulated high-resolution vertical scroll.
+	hwheel   int32         // Accumulated high-resolution horizontal scroll.
+}
+
+func newPadFilter(m *PadMapping, axes *AxisProcessor) *padFilter {
+	axes = axes.copy()
+	axes.Sticks = nil
+
+	for _, s := range []*PadStick{m.Pointer, m.Scroll} {
+		if s == nil {
+			continue
+		}
+
+		c := axes.Axes[s.X]
+		c.Deadzone = s.Deadzone
+		if c.Deadzone <= 0 {
+			c.Deadzone = DefaultStickDeadzone
+		}
+
+		c.Curve = s.Curve
+		if c.Curve == nil {
+			c.Curve = CurveEaseIn
+		}
+
+		axes.Axes[s.X] = c
+		axes.Sticks = append(axes.Sticks, [2]int{s.X, s.Y})
+	}
+
+	for axis := range m.Triggers {
+		c := axes.Axes[axis]
+		c.Trigger = true
+		axes.Axes[axis] = c
+	}
+
+	return &padFilter{
+		m:        m,
+		axes:     axes,
+		hats:     make(map[int]int),
+		triggers: make(map[int]bool),
+	}
+}
+
+// Feed implements timedFilter.
+func (p *padFilter) Feed(e Event) []Event {
+	var out []Event
+
+	switch e.Type {
+	case EvKeys:
+		if _, ok := p.m.Buttons[int(e.Code)]; ok {
+			out = p.m.Buttons.apply(e, out)
+		}
+
+	case EvAbsolute:
+		axis := int(e.Code)
+		t := eventTime(e)
+
+		if p.isStick(axis) {
+			if p.moving {
+				out = p.Tick(t)
+			}
+
+			p.axes.Set(axis, e.Value)
+
+			if !p.moving && p.pushed() {
+				p.moving = true
+				p.last = t
+			}
+
+			return out
+		}
+
+		p.axes.Set(axis, e.Value)
+
+		if keys, ok := p.m.Hats[axis]; ok {
+			out = append(out, p.hat(e, axis, keys)...)
+		}
+
+		if trig, ok := p.m.Triggers[axis]; ok {
+			out = append(out, p.trigger(e, axis, trig)...)
+		}
+	}
+
+	return out
+}
+
+// Tick implements timedFilter. It produces the motion and scrolling
+// since the previous frame.
+func (p *padFilter) Tick(now time.Duration) []Event {
+	if !p.moving {
+		return nil
+	}
+
+	dt := now - p.last
+	if dt > padMaxInterval {
+		dt = padMaxInterval
+	}
+
+	p.last = now
+	secs := dt.Seconds()
+	var out []Event
+
+	if s := p.m.Pointer; s != nil {
+		speed := s.Speed
+		if speed <= 0 {
+			speed = DefaultPointerSpeed
+		}
+
+		for i, axis := range [2]int{s.X, s.Y} {
+			p.rem[i] += p.axes.Value(axis) * speed * secs
+			n := math.Trunc(p.rem[i])
+			p.rem[i] -= n
+
+			if n != 0 {
+				out = append(out, Event{Type: EvRelative, Code: uint16(RelX + i), Value: int32(n)})
+			}
+		}
+	}
+
+	if s := p.m.Scroll; s != nil {
+		speed := s.Speed
+		if speed <= 0 {
+			speed = DefaultScrollSpeed
+		}
+
+		speed *= WheelHiResDetent
+
+		// Up is negative on a stick, but positive on a wheel.
+		p.scroll[0] -= p.axes.Value(s.Y) * speed * secs
+		p.scroll[1] += p.axes.Value(s.X) * speed * secs
+
+		v := math.Trunc(p.scroll[0])
+		h := math.Trunc(p.scroll[1])
+		p.scroll[0] -= v
+		p.scroll[1] -= h
+
+		out = wheelEvents(out, &p.wheel, int32(v), RelWheelHiRes, RelWheel)
+		out = wheelEvents(out, &p.hwheel, int32(h), RelHWheelHiRes, RelHWheel)
+	}
+
+	tv := timeEvent(now).Time
+	for i := range out {
+		out[i].Time = tv
+	}
+
+	if !p.pushed() {
+		p.moving = false
+		p.rem = [2]float64{}
+		p.scroll = [2]float64{}
+	}
+
+	return out
+}
+
+// Deadline implements timedFilter.
+func (p *padFilter) Deadline() (time.Duration, bool) {
+	return p.last + DefaultMotionInterval, p.moving
+}
+
+// Run implements Filter.
+func (p *padFilter) Run(in <-chan Frame, out chan<- Frame) {
+	runTimed(p, in, out, nil)
+}
+
+// hat handles a change of the hat axis.
+func (p *padFilter) hat(e Event, axis int, keys [2]int) []Event {
+	var dir int
+	if v := p.axes.Value(axis); math.Abs(v) >= padHatPress {
+		dir = int(math.Copysign(1, v))
+	}
+
+	old := p.hats[axis]
+	if dir == old {
+		return nil
+	}
+
+	p.hats[axis] = dir
+	var out []Event
+
+	if old != 0 {
+		out = append(out, Event{Time: e.Time, Type: EvKeys, Code: uint16(keys[(old+1)/2])})
+	}
+
+	if dir != 0 {
+		out = append(out, Event{Time: e.Time, Type: EvKeys, Code: uint16(keys[(dir+1)/2]), Value: 1})
+	}
+
+	return out
+}
+
+// trigger handles a change of the trigger axis.
+func (p *padFilter) trigger(e Event, axis int, trig PadTrigger) []Event {
+	press := trig.Press
+	if press <= 0 {
+		press = DefaultTriggerPress
+	}
+
+	v := p.axes.Value(axis)
+	down := p.triggers[axis]
+
+	switch {
+	case !down && v >= press:
+		down = true
+	case down && v < math.Max(press-padHysteresis, press/2):
+		down = false
+	default:
+		return nil
+	}
+
+	p.triggers[axis] = down
+
+	out := Event{Time: e.Time, Type: EvKeys, Code: uint16(trig.Code)}
+	if down {
+		out.Value = 1
+	}
+
+	return []Event{out}
+}
+
+// isStick returns true if the axis belongs to one of the sticks.
+func (p *padFilter) isStick(axis int) bool {
+	_, i := p.axes.stick(axis)
+	return i >= 0
+}
+
+// pushed returns true if any of the sticks is out of its deadzone.
+func (p *padFilter) pushed() bool {
+	for _, s := range p.axes.Sticks {
+		if p.axes.Value(s[0]) != 0 || p.axes.Value(s[1]) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// PadMapper reads a gamepad or joystick and drives the pointer and
+// keyboard through a virtual device, according to a PadMapping.
+// The gamepad itself is not grabbed; applications which read it,
+// such as games, still can.
+type PadMapper struct {
+	g      *grabber
+	filter *padFilter
+}
+
+// NewPadMapper creates a mapper for the given device, which takes
+// ownership of it; the device is closed if this fails. It uses the
+// first mapping which matches the identity of the device; or PadDesktop
+// if none are given.
+func NewPadMapper(dev *Device, mappings ...*PadMapping) (*PadMapper, error) {
+	if len(mappings) == 0 {
+		mappings = []*PadMapping{PadDesktop}
+	}
+
+	var m *PadMapping
+	for _, x := range mappings {
+		if x.Matches(dev.Id()) {
+			m = x
+			break
+		}
+	}
+
+	if m == nil {
+		dev.Close()
+		return nil, ErrNoPadMapping
+	}
+
+	caps := pointerCaps()
+	caps[EvRelative].Set(RelX)
+	caps[EvRelative].Set(RelY)
+
+	for _, code := range m.keyCodes() {
+		caps[EvKeys].Set(code)
+	}
+
+	virt, err := CreateVirtual(VirtualConfig{
+		Name:         mappedName(dev.Name()),
+		Id:           Id{BusType: BusVirtual},
+		Capabilities: caps,
+	})
+
+	if err != nil {
+		dev.Close()
+		return nil, err
+	}
+
+	g, err := newGrabber(virt, false, dev)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PadMapper{g: g, filter: newPadFilter(m, NewAxisProcessor(dev))}, nil
+}
+
+// mappedName returns the name of the virtual device for a gamepad
+// with the given name. The name is shortened to fit within
+// UinputMaxNameSize, if needed.
+func mappedName(name string) string {
+	const suffix = " (mapped)"
+	if max := UinputMaxNameSize - 1 - len(suffix); len(name) > max {
+		name = strings.ToValidUTF8(name[:max], "")
+	}
+	return name + suffix
+}
+
+// Virtual returns the virtual device which produces the pointer
+// motion and key presses.
+func (p *PadMapper) Virtual() *VirtualDevice {
+	return p.g.virt
+}
+
+// Run maps events until the mapper is closed, or until an error
+// occurs; like Remapper.Run.
+func (p *PadMapper) Run() error {
+	return p.g.run(func() error {
+		return NewPipeline(NewDeviceSource(p.g.devs[0]), p.g.virt, p.filter).Run()
+	})
+}
+
+// Close stops the mapper. It closes the device and destroys the
+// virtual device.
+func (p *PadMapper) Close() error {
+	return p.g.Close()
+}
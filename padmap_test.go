@@ -0,0 +1,119 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPadMapping(t *testing.T) {
+	stick := AbsInfo{Minimum: -100, Maximum: 100}
+
+	axes := &AxisProcessor{
+		Axes: map[int]AxisConfig{
+			AbsX:     {Info: stick},
+			AbsY:     {Info: stick},
+			AbsRX:    {Info: stick},
+			AbsRY:    {Info: stick},
+			AbsZ:     {Info: AbsInfo{Maximum: 200}},
+			AbsRZ:    {Info: AbsInfo{Maximum: 200}},
+			AbsHat0X: {Info: AbsInfo{Minimum: -1, Maximum: 1}},
+		},
+	}
+
+	m := &PadMapping{
+		Pointer:  &PadStick{X: AbsX, Y: AbsY, Speed: 1000, Deadzone: 0.2, Curve: CurveLinear},
+		Scroll:   &PadStick{X: AbsRX, Y: AbsRY, Speed: 10, Deadzone: 0.2, Curve: CurveLinear},
+		Buttons:  Mapping{BtnSouth: {BtnLeft}},
+		Hats:     map[int][2]int{AbsHat0X: {KeyLeft, KeyRight}},
+		Triggers: map[int]PadTrigger{AbsZ: {Code: BtnRight}, AbsRZ: {Code: BtnMiddle, Press: 0.04}},
+	}
+
+	p := newPadFilter(m, axes)
+
+	if axes.Sticks != nil || axes.Axes[AbsX].Deadzone != 0 || axes.Axes[AbsZ].Trigger {
+		t.Fatalf("newPadFilter: Changed the axis processor: %v", axes)
+	}
+
+	steps := []struct {
+		event Event
+		tick  int // Milliseconds; used if event is the zero value.
+		want  string
+	}{
+		{event: eventAt(EvAbsolute, AbsX, 0, 60)},
+		{tick: 10, want: "RelX 5"},
+		{event: eventAt(EvAbsolute, AbsX, 14, 0), want: "RelX 2"},
+		{tick: 22},
+		{event: eventAt(EvAbsolute, AbsRY, 100, -100)},
+		{tick: 150, want: "RelWheelHiRes 60"},
+		{tick: 200, want: "RelWheelHiRes 60; RelWheel 1"},
+		{event: eventAt(EvAbsolute, AbsRY, 210, 0), want: "RelWheelHiRes 12"},
+		{tick: 218},
+		{event: key(BtnSouth, 1), want: "BtnLeft 1"},
+		{event: key(BtnNorth, 1)},
+		{event: eventAt(EvAbsolute, AbsHat0X, 300, -1), want: "KeyLeft 1"},
+		{event: eventAt(EvAbsolute, AbsHat0X, 310, 1), want: "KeyLeft 0; KeyRight 1"},
+		{event: eventAt(EvAbsolute, AbsHat0X, 320, 0), want: "KeyRight 0"},
+		{event: eventAt(EvAbsolute, AbsZ, 400, 150), want: "BtnRight 1"},
+		{event: eventAt(EvAbsolute, AbsZ, 410, 95)},
+		{event: eventAt(EvAbsolute, AbsZ, 420, 80), want: "BtnRight 0"},
+		{event: eventAt(EvAbsolute, AbsRZ, 500, 10), want: "BtnMiddle 1"},
+		{event: eventAt(EvAbsolute, AbsRZ, 510, 5)},
+		{event: eventAt(EvAbsolute, AbsRZ, 520, 0), want: "BtnMiddle 0"},
+	}
+
+	for i, s := range steps {
+		var got []Event
+		if s.event == (Event{}) {
+			got = p.Tick(time.Duration(s.tick) * time.Millisecond)
+		} else {
+			got = p.Feed(s.event)
+		}
+
+		if trace := eventTrace(got); trace != s.want {
+			t.Fatalf("Step %d: Want %q, have %q", i, s.want, trace)
+		}
+	}
+
+	if d, ok := p.Deadline(); ok {
+		t.Fatalf("Still moving at the end, until %v", d)
+	}
+}
+
+func TestPadMappingKeyCodes(t *testing.T) {
+	m := &PadMapping{
+		Buttons:  Mapping{BtnSouth: {KeyLeftCtrl, KeyOk}},
+		Hats:     map[int][2]int{AbsHat0Y: {BtnDpadUp, BtnDpadDown}},
+		Triggers: map[int]PadTrigger{AbsZ: {Code: BtnLeft}},
+	}
+
+	want := []int{KeyLeftCtrl, BtnLeft, KeyOk, BtnDpadUp, BtnDpadDown}
+	have := m.keyCodes()
+	sort.Ints(have)
+
+	if !reflect.DeepEqual(have, want) {
+		t.Fatalf("Want %v, have %v", want, have)
+	}
+}
+
+func TestMappedName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Pad", "Pad (mapped)"},
+		{strings.Repeat("x", 100), strings.Repeat("x", 70) + " (mapped)"},
+		{strings.Repeat("x", 69) + "é", strings.Repeat("x", 69) + " (mapped)"},
+	}
+
+	for _, tt := range tests {
+		if have := mappedName(tt.name); have != tt.want {
+			t.Fatalf("%q: Want %q, have %q", tt.name, tt.want, have)
+		}
+	}
+}
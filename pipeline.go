@@ -0,0 +1,452 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// Frame holds the events which make up a single change of device state.
+// Devices separate frames with a SynReport event; frames passed through
+// a Pipeline do not include it. Sinks add it back when writing.
+type Frame []Event
+
+// Source provides frames to a Pipeline.
+type Source interface {
+	// ReadFrame returns the next frame. It blocks until one is
+	// available and returns io.EOF when there are no more.
+	ReadFrame() (Frame, error)
+}
+
+// Sink receives the frames which come out of a Pipeline.
+type Sink interface {
+	// WriteFrame writes the given frame.
+	WriteFrame(Frame) error
+}
+
+// Filter transforms frames as they pass through a Pipeline.
+//
+// Run reads frames from in and writes the frames it produces to out,
+// until in is closed. It can drop, modify and insert frames as it
+// sees fit; including ones which are produced on a timer, rather than
+// in response to an incoming frame. Run is called once, in a goroutine
+// of its own. The pipeline closes out when Run returns.
+type Filter interface {
+	Run(in <-chan Frame, out chan<- Frame)
+}
+
+// FilterFunc turns a function into a Filter. The function is called
+// for every frame and returns the frames to pass on in its place:
+// none to drop the frame, or several to insert new ones.
+type FilterFunc func(Frame) []Frame
+
+// Run implements Filter.
+func (fn FilterFunc) Run(in <-chan Frame, out chan<- Frame) {
+	for f := range in {
+		for _, g := range fn(f) {
+			out <- g
+		}
+	}
+}
+
+// timedFilter is implemented by filters whose output depends on the
+// passage of time, as well as on the events passed to them. Times are
+// in the same clock as the event timestamps.
+type timedFilter interface {
+	// Feed passes a single event to the filter and returns
+	// the events to pass on, if any.
+	Feed(e Event) []Event
+
+	// Tick tells the filter the given time has come and returns
+	// the events which are due by then.
+	Tick(now time.Duration) []Event
+
+	// Deadline returns the time at which Tick should next be called.
+	Deadline() (time.Duration, bool)
+}
+
+// runTimed runs a timedFilter as a Filter. Deadlines are measured
+// against the time of the most recent event. When in is closed,
+// finish is called, if set, for the events the filter still holds.
+func runTimed(f timedFilter, in <-chan Frame, out chan<- Frame, finish func(now time.Duration) []Event) {
+	var timer <-chan time.Time
+	var now, deadline time.Duration
+
+	send := func(list []Event) {
+		if len(list) > 0 {
+			out <- list
+		}
+	}
+
+	for {
+		select {
+		case g, ok := <-in:
+			if !ok {
+				if finish != nil {
+					send(finish(now))
+				}
+				return
+			}
+
+			var res Frame
+			for _, e := range g {
+				if t := eventTime(e); t > now {
+					now = t
+				}
+
+				res = append(res, f.Feed(e)...)
+			}
+
+			send(res)
+
+		case <-timer:
+			now = deadline
+			send(f.Tick(deadline))
+		}
+
+		timer = nil
+
+		if d, ok := f.Deadline(); ok {
+			deadline = d
+			timer = time.After(d - now)
+		}
+	}
+}
+
+// Pipeline reads frames from a source, passes them through a chain of
+// filters, in order, and writes the result to a sink.
+type Pipeline struct {
+	Source  Source
+	Filters []Filter
+	Sink    Sink
+}
+
+// NewPipeline creates a pipeline from the given parts.
+func NewPipeline(src Source, sink Sink, filters ...Filter) *Pipeline {
+	return &Pipeline{
+		Source:  src,
+		Filters: filters,
+		Sink:    sink,
+	}
+}
+
+// Run runs the pipeline until the source runs out of frames, or until
+// either the source or the sink fails. It returns nil in the first case
+// and the error otherwise.
+//
+// Frames still held by the filters when the source runs out, are
+// written before Run returns. When the sink fails, the source is read
+// no further; but a read which is in progress, may not return until
+// the source is closed.
+func (p *Pipeline) Run() error {
+	done := make(chan struct{})
+	src := make(chan Frame)
+
+	var srcErr error
+	go func() {
+		defer close(src)
+
+		for {
+			f, err := p.Source.ReadFrame()
+			if err != nil {
+				if err != io.EOF {
+					srcErr = err
+				}
+				return
+			}
+
+			select {
+			case src <- f:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	in := (<-chan Frame)(src)
+
+	for _, f := range p.Filters {
+		out := make(chan Frame)
+		wg.Add(1)
+
+		go func(f Filter, in <-chan Frame, out chan<- Frame) {
+			defer wg.Done()
+			defer close(out)
+			f.Run(in, out)
+		}(f, in, out)
+
+		in = out
+	}
+
+	var err error
+	for f := range in {
+		if err = p.Sink.WriteFrame(f); err != nil {
+			break
+		}
+	}
+
+	if err != nil {
+		close(done)
+
+		// Keep the filters going, so they notice the end of the input.
+		go func() {
+			for range in {
+			}
+		}()
+
+		return err
+	}
+
+	wg.Wait()
+	return srcErr
+}
+
+// DeviceSource reads frames from a device.
+//
+// If the kernel reports that events were dropped (SynDropped), the
+// incomplete frame is discarded and replaced by one which brings the
+// keys and buttons which changed in the meantime up to date. Other
+// state is not restored.
+type DeviceSource struct {
+	dev  *Device
+	buf  []Event
+	next []Event
+	keys Bitset // Keys reported as down so far.
+}
+
+// NewDeviceSource creates a source which reads frames from the given
+// device. The device may have been opened with or without goroutines;
+// in the former case, events are taken from its Inbox.
+func NewDeviceSource(dev *Device) *DeviceSource {
+	return &DeviceSource{
+		dev:  dev,
+		buf:  make([]Event, eventBufferSize),
+		keys: NewBitset(KeyCount),
+	}
+}
+
+// ReadFrame implements Source. It returns io.EOF once the device
+// has been closed.
+func (s *DeviceSource) ReadFrame() (Frame, error) {
+	var f Frame
+	dropped := false
+
+	for {
+		e, err := s.read()
+		if err != nil {
+			return nil, err
+		}
+
+		if e.Type != EvSync {
+			if !dropped {
+				f = append(f, e)
+			}
+			continue
+		}
+
+		switch e.Code {
+		case SynDropped:
+			dropped = true
+			f = nil
+
+		case SynReport:
+			if dropped {
+				f = s.resync()
+				dropped = false
+			}
+
+			if len(f) == 0 {
+				continue
+			}
+
+			s.track(f)
+			return f, nil
+
+		default:
+			if !dropped {
+				f = append(f, e)
+			}
+		}
+	}
+}
+
+// read returns the next event from the device.
+func (s *DeviceSource) read() (Event, error) {
+	if s.dev.Inbox != nil {
+		e, ok := <-s.dev.Inbox
+		if !ok {
+			return e, io.EOF
+		}
+
+		return e, nil
+	}
+
+	for len(s.next) == 0 {
+		n, err := s.dev.ReadEvents(s.buf)
+		if errors.Is(err, os.ErrClosed) || errors.Is(err, os.ErrInvalid) {
+			return Event{}, io.EOF
+		}
+
+		if err != nil {
+			return Event{}, err
+		}
+
+		s.next = s.buf[:n]
+	}
+
+	e := s.next[0]
+	s.next = s.next[1:]
+	return e, nil
+}
+
+// track keeps our view of the key state up to date.
+func (s *DeviceSource) track(f Frame) {
+	for _, e := range f {
+		if e.Type != EvKeys {
+			continue
+		}
+
+		if e.Value == 0 {
+			s.keys.Unset(int(e.Code))
+		} else {
+			s.keys.Set(int(e.Code))
+		}
+	}
+}
+
+// resync returns a frame with the key changes we missed.
+func (s *DeviceSource) resync() Frame {
+	state := s.dev.KeyState()
+
+	var f Frame
+	for code := 0; code < KeyCount; code++ {
+		down := state.Test(code)
+		if down == s.keys.Test(code) {
+			continue
+		}
+
+		var v int32
+		if down {
+			v = 1
+		}
+
+		f = append(f, Event{Type: EvKeys, Code: uint16(code), Value: v})
+	}
+
+	return f
+}
+
+// eventSize is the size of a single event, in bytes.
+const eventSize = int(unsafe.Sizeof(Event{}))
+
+// ReaderSource reads frames from a stream of events in the kernel's
+// struct input_event layout; e.g. a recording made with WriterSink.
+type ReaderSource struct {
+	r   io.Reader
+	buf [eventSize]byte
+}
+
+// NewReaderSource creates a source which reads frames from r.
+func NewReaderSource(r io.Reader) *ReaderSource {
+	return &ReaderSource{r: r}
+}
+
+// ReadFrame implements Source. An incomplete frame at the end of
+// the stream is discarded.
+func (s *ReaderSource) ReadFrame() (Frame, error) {
+	var f Frame
+
+	for {
+		_, err := io.ReadFull(s.r, s.buf[:])
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		e := *(*Event)(unsafe.Pointer(&s.buf[0]))
+
+		if e.Type == EvSync && e.Code == SynReport {
+			if len(f) > 0 {
+				return f, nil
+			}
+			continue
+		}
+
+		f = append(f, e)
+	}
+}
+
+// SliceSource is a Source which yields the frames it holds, in order.
+// This is mostly useful for testing.
+type SliceSource []Frame
+
+// ReadFrame implements Source.
+func (s *SliceSource) ReadFrame() (Frame, error) {
+	if len(*s) == 0 {
+		return nil, io.EOF
+	}
+
+	f := (*s)[0]
+	*s = (*s)[1:]
+	return f, nil
+}
+
+// ChanSource is a Source which yields the frames sent on the channel.
+// It returns io.EOF once the channel has been closed.
+type ChanSource <-chan Frame
+
+// ReadFrame implements Source.
+func (s ChanSource) ReadFrame() (Frame, error) {
+	f, ok := <-s
+	if !ok {
+		return nil, io.EOF
+	}
+
+	return f, nil
+}
+
+// ChanSink is a Sink which sends all frames on the channel.
+type ChanSink chan<- Frame
+
+// WriteFrame implements Sink.
+func (s ChanSink) WriteFrame(f Frame) error {
+	s <- f
+	return nil
+}
+
+// WriterSink writes frames in the kernel's struct input_event layout,
+// each followed by a SynReport; e.g. to record them to a file.
+type WriterSink struct {
+	w io.Writer
+}
+
+// NewWriterSink creates a sink which writes frames to w.
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+// WriteFrame implements Sink.
+func (s *WriterSink) WriteFrame(f Frame) error {
+	list := append(f[:len(f):len(f)], Event{Type: EvSync, Code: SynReport})
+	buf := unsafe.Slice((*byte)(unsafe.Pointer(&list[0])), len(list)*eventSize)
+
+	_, err := s.w.Write(buf)
+	return err
+}
+
+// WriteFrame writes the given frame, followed by a SynReport.
+// This makes a VirtualDevice usable as a Sink.
+func (v *VirtualDevice) WriteFrame(f Frame) error {
+	list := append(f[:len(f):len(f)], Event{Type: EvSync, Code: SynReport})
+	return v.WriteEvents(list...)
+}
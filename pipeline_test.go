@@ -0,0 +1,108 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// testSink collects the frames written to it.
+type testSink struct {
+	frames []Frame
+	err    error
+}
+
+func (s *testSink) WriteFrame(f Frame) error {
+	s.frames = append(s.frames, f)
+	return s.err
+}
+
+func key(code int, value int32) Event {
+	return Event{Type: EvKeys, Code: uint16(code), Value: value}
+}
+
+func TestPipeline(t *testing.T) {
+	src := SliceSource{
+		{key(KeyA, 1)},
+		{key(KeyB, 1)},
+		{key(KeyA, 0), key(KeyB, 0)},
+	}
+
+	// Drop everything involving KeyB.
+	dropB := FilterFunc(func(f Frame) []Frame {
+		var out Frame
+		for _, e := range f {
+			if e.Code != KeyB {
+				out = append(out, e)
+			}
+		}
+
+		if len(out) == 0 {
+			return nil
+		}
+		return []Frame{out}
+	})
+
+	// Follow every frame with a copy of itself.
+	double := FilterFunc(func(f Frame) []Frame {
+		return []Frame{f, f}
+	})
+
+	var sink testSink
+	if err := NewPipeline(&src, &sink, dropB, double).Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Frame{
+		{key(KeyA, 1)}, {key(KeyA, 1)},
+		{key(KeyA, 0)}, {key(KeyA, 0)},
+	}
+
+	if !reflect.DeepEqual(sink.frames, want) {
+		t.Fatalf("Want %v, have %v", want, sink.frames)
+	}
+
+	// A failing sink stops the pipeline.
+	src = SliceSource{{key(KeyA, 1)}, {key(KeyA, 0)}}
+	fail := errors.New("fail")
+	sink = testSink{err: fail}
+
+	if err := NewPipeline(&src, &sink, double).Run(); err != fail {
+		t.Fatalf("Failing sink: Want %v, have %v", fail, err)
+	}
+
+	if len(sink.frames) != 1 {
+		t.Fatalf("Failing sink: Want 1 frame, have %d", len(sink.frames))
+	}
+}
+
+func TestRecording(t *testing.T) {
+	frames := []Frame{
+		{key(KeyA, 1)},
+		{{Type: EvRelative, Code: RelX, Value: -3}, {Type: EvRelative, Code: RelY, Value: 4}},
+	}
+
+	var buf bytes.Buffer
+	src := SliceSource(frames)
+
+	if err := NewPipeline(&src, NewWriterSink(&buf)).Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := 5 * eventSize; buf.Len() != want {
+		t.Fatalf("Want %d bytes, have %d", want, buf.Len())
+	}
+
+	var sink testSink
+	if err := NewPipeline(NewReaderSource(&buf), &sink).Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(sink.frames, frames) {
+		t.Fatalf("Want %v, have %v", frames, sink.frames)
+	}
+}
@@ -0,0 +1,396 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import (
+	"math"
+	"time"
+)
+
+// DefaultMotionInterval is the default time between two motion
+// frames of a smooth pointer movement. This matches the polling
+// rate of a typical USB mouse; 125 Hz.
+const DefaultMotionInterval = 8 * time.Millisecond
+
+// Curve maps the progress of a movement in time onto the progress
+// in distance. Both are in the range [0, 1]. Curves must yield
+// 0 for 0 and 1 for 1.
+type Curve func(t float64) float64
+
+// Predefined curves for smooth pointer movements.
+var (
+	// CurveLinear moves at a constant speed.
+	CurveLinear Curve = func(t float64) float64 {
+		return t
+	}
+
+	// CurveEaseIn starts slowly and accelerates.
+	CurveEaseIn Curve = func(t float64) float64 {
+		return t * t
+	}
+
+	// CurveEaseOut starts quickly and decelerates.
+	CurveEaseOut Curve = func(t float64) float64 {
+		return t * (2 - t)
+	}
+
+	// CurveEaseInOut accelerates until halfway, then decelerates.
+	// This is closest to how people move a mouse.
+	CurveEaseInOut Curve = func(t float64) float64 {
+		if t < 0.5 {
+			return 2 * t * t
+		}
+		return -1 + (4-2*t)*t
+	}
+)
+
+// pointer holds what relative and absolute pointers have in common:
+// buttons and scroll wheels.
+type pointer struct {
+	*VirtualDevice
+
+	// Interval is the time between two motion frames of a smooth
+	// movement. It defaults to DefaultMotionInterval.
+	Interval time.Duration
+
+	// Hold is the time a button is held down while clicking.
+	Hold time.Duration
+
+	wheel  int32 // Accumulated high-resolution vertical scroll.
+	hwheel int32 // Accumulated high-resolution horizontal scroll.
+}
+
+// pointerCaps returns the capabilities shared by all pointers.
+func pointerCaps() map[int]Bitset {
+	btn := NewBitset(KeyCount)
+	for code := BtnLeft; code <= BtnTask; code++ {
+		btn.Set(code)
+	}
+
+	rel := NewBitset(RelCount)
+	rel.Set(RelWheel)
+	rel.Set(RelHWheel)
+	rel.Set(RelWheelHiRes)
+	rel.Set(RelHWheelHiRes)
+
+	return map[int]Bitset{
+		EvKeys:     btn,
+		EvRelative: rel,
+	}
+}
+
+// Press presses the given button (BtnXXX), without releasing it.
+func (p *pointer) Press(btn int) error {
+	return p.frame(Event{Type: EvKeys, Code: uint16(btn), Value: 1})
+}
+
+// Release releases the given button.
+func (p *pointer) Release(btn int) error {
+	return p.frame(Event{Type: EvKeys, Code: uint16(btn), Value: 0})
+}
+
+// Click presses and releases the given button.
+func (p *pointer) Click(btn int) error {
+	err := p.Press(btn)
+	if err != nil {
+		return err
+	}
+
+	time.Sleep(p.Hold)
+	return p.Release(btn)
+}
+
+// Scroll turns the scroll wheels by the given number of notches.
+// Positive values scroll up and right, respectively. Fractions of
+// a notch are sent through the high-resolution wheel; the regular
+// wheel follows as soon as a full notch has been accumulated.
+func (p *pointer) Scroll(vertical, horizontal float64) error {
+	return p.ScrollHiRes(
+		int32(math.Floor(vertical*WheelHiResDetent+0.5)),
+		int32(math.Floor(horizontal*WheelHiResDetent+0.5)),
+	)
+}
+
+// ScrollHiRes turns the scroll wheels by the given high-resolution
+// amounts. See WheelHiResDetent.
+func (p *pointer) ScrollHiRes(vertical, horizontal int32) error {
+	var list []Event
+	list = wheelEvents(list, &p.wheel, vertical, RelWheelHiRes, RelWheel)
+	list = wheelEvents(list, &p.hwheel, horizontal, RelHWheelHiRes, RelHWheel)
+
+	if len(list) == 0 {
+		return nil
+	}
+
+	return p.frame(list...)
+}
+
+// wheelEvents appends the events for turning a scroll wheel by the
+// given high-resolution amount, to list. The amount is accumulated in
+// acc, so the regular wheel can follow once a full notch is reached.
+func wheelEvents(list []Event, acc *int32, v int32, hires, code int) []Event {
+	if v == 0 {
+		return list
+	}
+
+	*acc += v
+	list = append(list, Event{Type: EvRelative, Code: uint16(hires), Value: v})
+
+	if n := *acc / WheelHiResDetent; n != 0 {
+		*acc -= n * WheelHiResDetent
+		list = append(list, Event{Type: EvRelative, Code: uint16(code), Value: n})
+	}
+
+	return list
+}
+
+// frame sends the given events, followed by a SynReport.
+func (p *pointer) frame(list ...Event) error {
+	list = append(list, Event{Type: EvSync, Code: SynReport})
+	return p.WriteEvents(list...)
+}
+
+// smooth calls fn once per motion frame, with the progress of the
+// movement in the range (0, 1], until it has been completed.
+func (p *pointer) smooth(d time.Duration, c Curve, fn func(float64) error) error {
+	if c == nil {
+		c = CurveLinear
+	}
+
+	steps := motionSteps(d, p.Interval)
+
+	for i := 1; i <= steps; i++ {
+		if i > 1 {
+			time.Sleep(p.Interval)
+		}
+
+		pos := 1.0
+		if i < steps {
+			pos = c(float64(i) / float64(steps))
+		}
+
+		if err := fn(pos); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// motionSteps returns the number of motion frames needed
+// to cover the given duration.
+func motionSteps(d, interval time.Duration) int {
+	if interval <= 0 {
+		interval = DefaultMotionInterval
+	}
+
+	steps := int(d / interval)
+	if steps < 1 {
+		steps = 1
+	}
+
+	return steps
+}
+
+// VirtualMouse is a virtual device which behaves like a regular
+// mouse with relative motion, buttons and scroll wheels.
+type VirtualMouse struct {
+	pointer
+}
+
+// NewVirtualMouse creates a virtual mouse with the given name.
+func NewVirtualMouse(name string) (*VirtualMouse, error) {
+	caps := pointerCaps()
+	caps[EvRelative].Set(RelX)
+	caps[EvRelative].Set(RelY)
+
+	props := NewBitset(InputPropCount)
+	props.Set(InputPropPointer)
+
+	dev, err := CreateVirtual(VirtualConfig{
+		Name:         name,
+		Id:           Id{BusType: BusVirtual},
+		Properties:   props,
+		Capabilities: caps,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	m := new(VirtualMouse)
+	m.VirtualDevice = dev
+	m.Interval = DefaultMotionInterval
+	return m, nil
+}
+
+// Move moves the pointer by the given amount in a single frame.
+func (m *VirtualMouse) Move(dx, dy int) error {
+	var list []Event
+
+	if dx != 0 {
+		list = append(list, Event{Type: EvRelative, Code: RelX, Value: int32(dx)})
+	}
+
+	if dy != 0 {
+		list = append(list, Event{Type: EvRelative, Code: RelY, Value: int32(dy)})
+	}
+
+	if len(list) == 0 {
+		return nil
+	}
+
+	return m.frame(list...)
+}
+
+// MoveSmooth moves the pointer by the given amount, spread out over
+// the given duration. The curve determines how the speed changes
+// along the way; nil means CurveLinear. The sum of all the frames
+// is exactly (dx, dy).
+func (m *VirtualMouse) MoveSmooth(dx, dy int, d time.Duration, c Curve) error {
+	var x, y int
+
+	return m.smooth(d, c, func(pos float64) error {
+		nx := int(math.Round(pos * float64(dx)))
+		ny := int(math.Round(pos * float64(dy)))
+
+		err := m.Move(nx-x, ny-y)
+		x, y = nx, ny
+		return err
+	})
+}
+
+// Drag holds down the given button while moving the pointer
+// by the given amount. See MoveSmooth.
+func (m *VirtualMouse) Drag(btn, dx, dy int, d time.Duration, c Curve) error {
+	err := m.Press(btn)
+	if err != nil {
+		return err
+	}
+
+	err = m.MoveSmooth(dx, dy, d, c)
+	if err != nil {
+		m.Release(btn)
+		return err
+	}
+
+	return m.Release(btn)
+}
+
+// VirtualAbsPointer is a virtual device which positions the pointer
+// at absolute screen coordinates, like a graphics tablet in mouse
+// mode or the pointer of a virtual machine.
+//
+// Screen coordinates are mapped onto the range of the device's axes.
+// The receiving end maps them back onto the screen, so the device
+// need not know the actual screen resolution; it suffices that the
+// width and height are in proportion to it.
+type VirtualAbsPointer struct {
+	pointer
+
+	Width  int // Width of the screen.
+	Height int // Height of the screen.
+
+	xaxis AbsInfo
+	yaxis AbsInfo
+	x, y  int // Current position in screen coordinates.
+}
+
+// NewVirtualAbsPointer creates a virtual absolute pointer with the given
+// name, for a screen of the given size. The axis information determines
+// the range of the device's AbsX and AbsY axes. A zero value yields
+// the range 0-32767.
+func NewVirtualAbsPointer(name string, width, height int, axis AbsInfo) (*VirtualAbsPointer, error) {
+	if axis.Maximum <= axis.Minimum {
+		axis = AbsInfo{Minimum: 0, Maximum: 32767}
+	}
+
+	dev, err := CreateVirtual(VirtualConfig{
+		Name:         name,
+		Id:           Id{BusType: BusVirtual},
+		Capabilities: pointerCaps(),
+		Absolute: map[int]AbsInfo{
+			AbsX: axis,
+			AbsY: axis,
+		},
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	p := new(VirtualAbsPointer)
+	p.VirtualDevice = dev
+	p.Interval = DefaultMotionInterval
+	p.Width = width
+	p.Height = height
+	p.xaxis = axis
+	p.yaxis = axis
+	return p, nil
+}
+
+// Position returns the current pointer position in screen coordinates.
+func (p *VirtualAbsPointer) Position() (int, int) {
+	return p.x, p.y
+}
+
+// MoveTo moves the pointer to the given screen coordinates
+// in a single frame.
+func (p *VirtualAbsPointer) MoveTo(x, y int) error {
+	p.x, p.y = x, y
+
+	return p.frame(
+		Event{Type: EvAbsolute, Code: AbsX, Value: screenToAbs(x, p.Width, p.xaxis)},
+		Event{Type: EvAbsolute, Code: AbsY, Value: screenToAbs(y, p.Height, p.yaxis)},
+	)
+}
+
+// MoveToSmooth moves the pointer from its current position to the given
+// screen coordinates, spread out over the given duration. The curve
+// determines how the speed changes along the way; nil means CurveLinear.
+func (p *VirtualAbsPointer) MoveToSmooth(x, y int, d time.Duration, c Curve) error {
+	x0, y0 := p.x, p.y
+
+	return p.smooth(d, c, func(pos float64) error {
+		return p.MoveTo(
+			x0+int(math.Round(pos*float64(x-x0))),
+			y0+int(math.Round(pos*float64(y-y0))),
+		)
+	})
+}
+
+// DragTo holds down the given button while moving the pointer
+// to the given screen coordinates. See MoveToSmooth.
+func (p *VirtualAbsPointer) DragTo(btn, x, y int, d time.Duration, c Curve) error {
+	err := p.Press(btn)
+	if err != nil {
+		return err
+	}
+
+	err = p.MoveToSmooth(x, y, d, c)
+	if err != nil {
+		p.Release(btn)
+		return err
+	}
+
+	return p.Release(btn)
+}
+
+// screenToAbs maps the given screen coordinate onto the axis range.
+func screenToAbs(v, size int, axis AbsInfo) int32 {
+	if size <= 1 {
+		return axis.Minimum
+	}
+
+	if v < 0 {
+		v = 0
+	}
+
+	if v >= size {
+		v = size - 1
+	}
+
+	span := float64(axis.Maximum - axis.Minimum)
+	return axis.Minimum + int32(math.Round(float64(v)*span/float64(size-1)))
+}
@@ -0,0 +1,58 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScreenToAbs(t *testing.T) {
+	axis := AbsInfo{Minimum: 0, Maximum: 32767}
+
+	tests := []struct {
+		v, size int
+		want    int32
+	}{
+		{0, 1920, 0},
+		{1919, 1920, 32767},
+		{960, 1920, 16392},
+		{-10, 1920, 0},
+		{5000, 1920, 32767},
+		{3, 1, 0},
+	}
+
+	for _, tt := range tests {
+		if got := screenToAbs(tt.v, tt.size, axis); got != tt.want {
+			t.Fatalf("screenToAbs(%d, %d): Want %d, have %d", tt.v, tt.size, tt.want, got)
+		}
+	}
+}
+
+func TestCurves(t *testing.T) {
+	for name, c := range map[string]Curve{
+		"linear":    CurveLinear,
+		"easein":    CurveEaseIn,
+		"easeout":   CurveEaseOut,
+		"easeinout": CurveEaseInOut,
+	} {
+		if c(0) != 0 || c(1) != 1 {
+			t.Fatalf("%s: Want 0-1, have %v-%v", name, c(0), c(1))
+		}
+
+		for v := 0.1; v < 1; v += 0.1 {
+			if c(v) < c(v-0.1) {
+				t.Fatalf("%s: Not monotonic at %v", name, v)
+			}
+		}
+	}
+
+	if n := motionSteps(100*time.Millisecond, 8*time.Millisecond); n != 12 {
+		t.Fatalf("motionSteps: Want 12, have %d", n)
+	}
+
+	if n := motionSteps(0, 0); n != 1 {
+		t.Fatalf("motionSteps: Want 1, have %d", n)
+	}
+}
@@ -0,0 +1,96 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import "os"
+
+// DeviceRef is a lightweight handle to a device node.
+//
+// It holds the node path along with a snapshot of the device's
+// identity and capabilities, taken while scanning. The node itself
+// is not kept open: no reader goroutines are running and no events
+// are consumed until DeviceRef.Open is called.
+type DeviceRef struct {
+	Node   string // Device node, e.g.: /dev/input/event3
+	Name   string // Device name. See Device.Name.
+	Path   string // Physical path. See Device.Path.
+	Serial string // Unique serial code. See Device.Serial.
+	Id     Id     // Device identity.
+
+	props Bitset
+	caps  [EvCount]Bitset
+}
+
+// NewDeviceRef inspects the given node and returns a reference to it.
+// The node is opened read-only for the duration of this call only.
+func NewDeviceRef(node string) (*DeviceRef, error) {
+	fd, err := os.OpenFile(node, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	defer fd.Close()
+
+	dev := &Device{fd: fd}
+
+	// Make sure this is actually an evdev node.
+	if _, _, _, ok := dev.version(); !ok {
+		return nil, ErrNotEvdev
+	}
+
+	ref := new(DeviceRef)
+	ref.Node = node
+	ref.Name = dev.Name()
+	ref.Path = dev.Path()
+	ref.Serial = dev.Serial()
+	ref.Id = dev.Id()
+	ref.props = dev.Properties()
+	ref.caps[EvSync] = dev.EventTypes()
+
+	for evtype := 1; evtype < EvCount; evtype++ {
+		if ref.caps[EvSync].Test(evtype) {
+			ref.caps[evtype] = dev.Capabilities(evtype)
+		}
+	}
+
+	return ref, nil
+}
+
+// Open opens the referenced node.
+func (r *DeviceRef) Open() (*Device, error) {
+	return Open(r.Node)
+}
+
+// OpenWith opens the referenced node using the given options.
+func (r *DeviceRef) OpenWith(opt Options) (*Device, error) {
+	return OpenWith(r.Node, opt)
+}
+
+// EventTypes returns the cached set of supported event types.
+// See Device.EventTypes.
+func (r *DeviceRef) EventTypes() Bitset {
+	return r.caps[EvSync]
+}
+
+// Capabilities returns the cached set of supported codes for the given
+// event type. See Device.Capabilities.
+func (r *DeviceRef) Capabilities(evtype int) Bitset {
+	if evtype < 0 || evtype >= EvCount {
+		return nil
+	}
+
+	return r.caps[evtype]
+}
+
+// Properties returns the cached set of device properties.
+// See Device.Properties.
+func (r *DeviceRef) Properties() Bitset {
+	return r.props
+}
+
+// Test tests the given bitset against a list of constants.
+// See Device.Test.
+func (r *DeviceRef) Test(set Bitset, values ...int) bool {
+	return testBits(set, values...)
+}
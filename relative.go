@@ -13,21 +13,33 @@ import "unsafe"
 //
 // RelWheel and RelHWheel are used for vertical and horizontal scroll
 // wheels, respectively.
+//
+// RelWheelHiRes and RelHWheelHiRes are their high-resolution
+// counterparts. A value of 120 equals one notch of the regular wheel.
+// Devices which emit these, should emit the regular wheel events as
+// well, once the accumulated high-resolution value reaches a full notch.
 const (
-	RelX      = 0x00
-	RelY      = 0x01
-	RelZ      = 0x02
-	RelRX     = 0x03
-	RelRY     = 0x04
-	RelRZ     = 0x05
-	RelHWheel = 0x06
-	RelDial   = 0x07
-	RelWheel  = 0x08
-	RelMisc   = 0x09
-	RelMax    = 0x0f
-	RelCount  = RelMax + 1
+	RelX           = 0x00
+	RelY           = 0x01
+	RelZ           = 0x02
+	RelRX          = 0x03
+	RelRY          = 0x04
+	RelRZ          = 0x05
+	RelHWheel      = 0x06
+	RelDial        = 0x07
+	RelWheel       = 0x08
+	RelMisc        = 0x09
+	RelReserved    = 0x0a
+	RelWheelHiRes  = 0x0b
+	RelHWheelHiRes = 0x0c
+	RelMax         = 0x0f
+	RelCount       = RelMax + 1
 )
 
+// WheelHiResDetent is the high-resolution wheel value
+// which equals one notch of a regular scroll wheel.
+const WheelHiResDetent = 120
+
 // RelativeAxes returns a bitfield indicating which relative axes are
 // supported by the device.
 //
@@ -35,6 +47,6 @@ const (
 func (d *Device) RelativeAxes() Bitset {
 	bs := NewBitset(RelMax)
 	buf := bs.Bytes()
-	ioctl(d.fd.Fd(), _EVIOCGBIT(EvRelative, len(buf)), unsafe.Pointer(&buf[0]))
+	d.ioctl(_EVIOCGBIT(EvRelative, len(buf)), unsafe.Pointer(&buf[0]))
 	return bs
 }
@@ -0,0 +1,276 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrGrab is returned when exclusive access to a device
+// can not be obtained.
+var ErrGrab = errors.New("evdev: unable to grab device")
+
+// RemapSettleTime is the longest time NewRemapper waits for the keys
+// on a device to be released, before grabbing it.
+var RemapSettleTime = 2 * time.Second
+
+// Mapping maps keys and buttons (KeyXXX, BtnXXX) onto the keys and
+// buttons they should produce instead. A single code remaps a key;
+// several codes turn it into a combination, which is pressed in order
+// and released in reverse order. An empty list disables the key.
+// Keys which are not listed are passed on unchanged.
+//
+//	Mapping{
+//		KeyCapsLock: {KeyLeftCtrl},         // CapsLock -> Ctrl
+//		BtnLeft:     {BtnRight},            // Swap mouse buttons.
+//		BtnRight:    {BtnLeft},
+//		BtnSide:     {KeyLeftAlt, KeyLeft}, // Back button -> Alt+Left
+//		KeyInsert:   {},                    // Disable Insert.
+//	}
+type Mapping map[int][]int
+
+// Codes returns all the codes the mapping produces.
+func (m Mapping) Codes() []int {
+	var list []int
+	for _, codes := range m {
+		list = append(list, codes...)
+	}
+	return list
+}
+
+// Filter returns a filter which applies the mapping.
+// Key repeats are applied to the last key of a combination.
+func (m Mapping) Filter() Filter {
+	return FilterFunc(func(f Frame) []Frame {
+		out := make(Frame, 0, len(f))
+		for _, e := range f {
+			out = m.apply(e, out)
+		}
+
+		if len(out) == 0 {
+			return nil
+		}
+
+		return []Frame{out}
+	})
+}
+
+// apply appends the events the given event maps onto, to out.
+func (m Mapping) apply(e Event, out Frame) Frame {
+	codes, ok := m[int(e.Code)]
+	if e.Type != EvKeys || !ok {
+		return append(out, e)
+	}
+
+	switch {
+	case len(codes) == 0:
+
+	case e.Value == 0:
+		for i := len(codes) - 1; i >= 0; i-- {
+			out = append(out, mapKey(e, codes[i]))
+		}
+
+	case e.Value == 1:
+		for _, code := range codes {
+			out = append(out, mapKey(e, code))
+		}
+
+	default:
+		out = append(out, mapKey(e, codes[len(codes)-1]))
+	}
+
+	return out
+}
+
+// mapKey returns a copy of the given key event, with a different code.
+func mapKey(e Event, code int) Event {
+	e.Code = uint16(code)
+	return e
+}
+
+// Remapper grabs a device and passes its events on through a virtual
+// clone, after remapping its keys and buttons. Other applications only
+// see the clone.
+type Remapper struct {
+	g       *grabber
+	mapping Mapping
+}
+
+// NewRemapper creates a remapper for the given device, which takes
+// ownership of it; the device is closed if this fails. It creates the
+// clone, then waits for all keys on the device to be released (up to
+// RemapSettleTime), so none of them remain stuck once the device has
+// been grabbed; then grabs the device.
+func NewRemapper(dev *Device, m Mapping) (*Remapper, error) {
+	virt, err := CloneDevice(dev, CloneOptions{
+		Add: map[int][]int{EvKeys: m.Codes()},
+	})
+
+	if err != nil {
+		dev.Close()
+		return nil, err
+	}
+
+	g, err := newGrabber(virt, true, dev)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Remapper{g: g, mapping: m}, nil
+}
+
+// Virtual returns the virtual clone which receives the remapped events.
+func (r *Remapper) Virtual() *VirtualDevice {
+	return r.g.virt
+}
+
+// Run remaps events until the remapper is closed, or until an error
+// occurs. Either way, the remapper is closed when Run returns.
+// It returns nil if it was stopped through Close.
+func (r *Remapper) Run() error {
+	return r.g.run(func() error {
+		return NewPipeline(NewDeviceSource(r.g.devs[0]), r.g.virt, r.mapping.Filter()).Run()
+	})
+}
+
+// Close stops the remapper. It releases the grab, closes the device
+// and destroys the virtual clone. Keys which are held down on the clone
+// at that moment, are released by the kernel.
+func (r *Remapper) Close() error {
+	return r.g.Close()
+}
+
+// grabber implements the life cycle shared by Remapper and the other
+// mappers: it owns the devices they read, and the virtual device they
+// write to.
+type grabber struct {
+	devs    []*Device
+	virt    *VirtualDevice
+	mu      sync.Mutex     // Guards the start of run against Close.
+	running sync.WaitGroup // Calls to run which are in progress.
+	stop    sync.Once
+	stopped chan struct{} // Closed by interrupt.
+	once    sync.Once
+	closed  chan struct{} // Closed by Close.
+}
+
+// newGrabber takes ownership of the given devices. If grab is set, it
+// waits for the keys on each device to be released (see settle), then
+// grabs it. On failure, the devices are closed and the virtual device
+// is destroyed.
+func newGrabber(virt *VirtualDevice, grab bool, devs ...*Device) (*grabber, error) {
+	for i := 0; grab && i < len(devs); i++ {
+		settle(devs[i])
+
+		if !devs[i].Grab() {
+			closeDevices(devs)
+			virt.Close()
+			return nil, ErrGrab
+		}
+	}
+
+	return &grabber{
+		devs:    devs,
+		virt:    virt,
+		stopped: make(chan struct{}),
+		closed:  make(chan struct{}),
+	}, nil
+}
+
+// run passes events on through fn, which reads the devices until they
+// run out or are interrupted. The grabber is closed when fn returns;
+// run returns nil if this was caused by Close.
+func (g *grabber) run(fn func() error) error {
+	g.mu.Lock()
+	select {
+	case <-g.closed:
+		g.mu.Unlock()
+		return nil
+	default:
+		g.running.Add(1)
+		g.mu.Unlock()
+	}
+
+	err := fn()
+	g.running.Done()
+
+	select {
+	case <-g.closed:
+		return nil
+	default:
+		g.Close()
+		return err
+	}
+}
+
+// interrupt makes the reads from the devices fail, so run returns.
+// The devices stay open until Close, since other goroutines may still
+// be reading them.
+func (g *grabber) interrupt() {
+	g.stop.Do(func() {
+		close(g.stopped)
+
+		for _, dev := range g.devs {
+			dev.interrupt()
+		}
+	})
+}
+
+// Close interrupts run and waits for it to return. Then it releases
+// the grabs, closes the devices and destroys the virtual device.
+func (g *grabber) Close() error {
+	var err error
+
+	g.once.Do(func() {
+		g.mu.Lock()
+		close(g.closed)
+		g.mu.Unlock()
+
+		g.interrupt()
+		g.running.Wait()
+
+		for _, dev := range g.devs {
+			dev.Release()
+			if derr := dev.Close(); err == nil {
+				err = derr
+			}
+		}
+
+		if verr := g.virt.Close(); err == nil {
+			err = verr
+		}
+	})
+
+	return err
+}
+
+// closeDevices releases and closes the given devices. It is used to
+// clean up devices whose ownership was passed to a constructor which
+// failed.
+func closeDevices(devs []*Device) {
+	for _, dev := range devs {
+		dev.Close()
+	}
+}
+
+// settle waits for all keys on the device to be released,
+// for up to RemapSettleTime.
+func settle(dev *Device) {
+	deadline := time.Now().Add(RemapSettleTime)
+	for keysDown(dev) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// keysDown returns true if any key or button on the device is down.
+func keysDown(dev *Device) bool {
+	for _, w := range dev.KeyState() {
+		if w != 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMapping(t *testing.T) {
+	m := Mapping{
+		KeyCapsLock: {KeyLeftCtrl},
+		BtnSide:     {KeyLeftAlt, KeyLeft},
+		KeyInsert:   {},
+	}
+
+	rel := Event{Type: EvRelative, Code: RelX, Value: 1}
+
+	src := SliceSource{
+		{key(KeyCapsLock, 1), rel},
+		{key(KeyCapsLock, 2)},
+		{key(KeyInsert, 1)},
+		{key(BtnSide, 1)},
+		{key(BtnSide, 2)},
+		{key(BtnSide, 0), key(KeyA, 1)},
+	}
+
+	var sink testSink
+	if err := NewPipeline(&src, &sink, m.Filter()).Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Frame{
+		{key(KeyLeftCtrl, 1), rel},
+		{key(KeyLeftCtrl, 2)},
+		{key(KeyLeftAlt, 1), key(KeyLeft, 1)},
+		{key(KeyLeft, 2)},
+		{key(KeyLeft, 0), key(KeyLeftAlt, 0), key(KeyA, 1)},
+	}
+
+	if !reflect.DeepEqual(sink.frames, want) {
+		t.Fatalf("Want %v, have %v", want, sink.frames)
+	}
+}
+
+func TestRemapperClose(t *testing.T) {
+	dev, w := pipeDevice(t)
+	defer w.Close()
+
+	virt, out := pipeVirtual(t)
+	defer out.Close()
+
+	g, err := newGrabber(virt, false, dev)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := &Remapper{g: g, mapping: Mapping{KeyA: {KeyB}}}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- r.Run()
+	}()
+
+	err = writeEvents(w, []Event{key(KeyA, 1), {Type: EvSync, Code: SynReport}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Event{key(KeyB, 1), {Type: EvSync, Code: SynReport}}
+	if have := readPipe(t, out, 2); !reflect.DeepEqual(have, want) {
+		t.Fatalf("Remapped: Want %v, have %v", want, have)
+	}
+
+	// Run is waiting for the next event; Close must stop it.
+	r.Close()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Run: Want <nil>, have %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Run: not stopped by Close")
+	}
+}
+
+func TestGrabberFailure(t *testing.T) {
+	dev1, w1 := pipeDevice(t)
+	defer w1.Close()
+
+	dev2, w2 := pipeDevice(t)
+	defer w2.Close()
+
+	virt, out := pipeVirtual(t)
+	defer out.Close()
+
+	// Pipes can not be grabbed.
+	if _, err := newGrabber(virt, true, dev1, dev2); err != ErrGrab {
+		t.Fatalf("newGrabber: Want %v, have %v", ErrGrab, err)
+	}
+
+	for i, dev := range []*Device{dev1, dev2} {
+		if _, err := dev.fd.Read(make([]byte, 1)); !errors.Is(err, os.ErrClosed) {
+			t.Fatalf("Device %d: Want %v, have %v", i, os.ErrClosed, err)
+		}
+	}
+
+	if _, err := virt.fd.Write(make([]byte, 1)); !errors.Is(err, os.ErrClosed) {
+		t.Fatalf("Virtual device: Want %v, have %v", os.ErrClosed, err)
+	}
+}
@@ -23,7 +23,7 @@ const (
 // This is only applicable to devices with EvRepeat event support.
 func (d *Device) RepeatState() (uint, uint) {
 	var rep [2]int32
-	ioctl(d.fd.Fd(), _EVIOCGREP, unsafe.Pointer(&rep[0]))
+	d.ioctl(_EVIOCGREP, unsafe.Pointer(&rep[0]))
 	return uint(rep[0]), uint(rep[1])
 }
 
@@ -47,5 +47,19 @@ func (d *Device) SetRepeatState(initial, subsequent uint) bool {
 	var rep [2]int32
 	rep[0] = int32(initial)
 	rep[1] = int32(subsequent)
-	return ioctl(d.fd.Fd(), _EVIOCSREP, unsafe.Pointer(&rep[0])) == nil
+	return d.ioctl(_EVIOCSREP, unsafe.Pointer(&rep[0])) == nil
+}
+
+// SetRepeatState sets the autorepeat delay and period for the
+// virtual device. Refer to Device.SetRepeatState for an explanation
+// of the values.
+//
+// This is only applicable to devices with EvRepeat event support.
+func (v *VirtualDevice) SetRepeatState(initial, subsequent uint) error {
+	err := v.Emit(EvRepeat, RepDelay, int32(initial))
+	if err == nil {
+		err = v.Emit(EvRepeat, RepPeriod, int32(subsequent))
+	}
+
+	return err
 }
@@ -6,7 +6,10 @@ package evdev
 import (
 	"errors"
 	"fmt"
-	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 // List of device types.
@@ -25,22 +28,57 @@ const (
 	Joystick
 )
 
-// Find returns a list of all attached devices, which
-// qualify as the given device type.
-func Find(devtype int) (list []*Device, err error) {
-	// Ensure we clean up properly if something goes wrong.
-	defer func() {
-		if err != nil {
-			for _, dev := range list {
-				dev.Close()
-			}
-			list = nil
-		}
-	}()
+// InputDir is the directory scanned by Find for device nodes.
+const InputDir = "/dev/input"
 
-	var count int
-	var dev *Device
-	var testFunc func(*Device) bool
+// NodeError records a failure to open or inspect a single
+// device node during a scan.
+type NodeError struct {
+	Node string // Device node, e.g.: /dev/input/event3
+	Err  error  // The error encountered.
+}
+
+func (e *NodeError) Error() string {
+	return e.Node + ": " + e.Err.Error()
+}
+
+// NodeErrors is the per-node error report returned by Find.
+// It lists every node which could not be opened or inspected.
+// The nodes which did succeed are still returned alongside it.
+type NodeErrors []*NodeError
+
+func (e NodeErrors) Error() string {
+	switch len(e) {
+	case 0:
+		return "no errors"
+	case 1:
+		return e[0].Error()
+	}
+
+	return fmt.Sprintf("%s (and %d other nodes)", e[0].Error(), len(e)-1)
+}
+
+// Capable is implemented by anything which can report
+// the event types supported by a device. Both Device and
+// DeviceRef qualify.
+type Capable interface {
+	EventTypes() Bitset
+}
+
+// Find returns references to all attached devices, which
+// qualify as the given device type.
+//
+// The devices are not kept open. Use DeviceRef.Open
+// on the ones you are actually interested in.
+//
+// Every node matching /dev/input/event* is examined. Nodes which
+// can not be opened (e.g.: due to insufficient permissions) are
+// skipped and the search carries on with the next one. If any
+// such failures occurred, the returned error is of type NodeErrors
+// and the returned list still holds all the devices which did
+// qualify. Any other error means the search could not be performed.
+func Find(devtype int) (list []*DeviceRef, err error) {
+	var testFunc func(Capable) bool
 
 	switch devtype {
 	case Keyboard:
@@ -50,42 +88,98 @@ func Find(devtype int) (list []*Device, err error) {
 	case Joystick:
 		testFunc = IsJoystick
 	default:
-		err = errors.New("Invalid device type")
-		return
+		return nil, errors.New("Invalid device type")
 	}
 
-	for {
-		node := fmt.Sprintf("/dev/input/event%d", count)
-		dev, err = Open(node)
+	refs, err := Enumerate()
+	if _, ok := err.(NodeErrors); err != nil && !ok {
+		return nil, err
+	}
 
-		if err != nil {
-			if os.IsNotExist(err) {
-				err = nil
-			}
-			return
+	for _, ref := range refs {
+		if testFunc(ref) {
+			list = append(list, ref)
 		}
+	}
 
-		count++
+	return list, err
+}
 
-		if testFunc(dev) {
-			list = append(list, dev)
+// Enumerate returns references to all attached devices.
+//
+// Error handling is the same as for Find: nodes which can
+// not be inspected are reported through NodeErrors.
+func Enumerate() (list []*DeviceRef, err error) {
+	nodes, err := eventNodes(InputDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs NodeErrors
+
+	for _, node := range nodes {
+		ref, err := NewDeviceRef(node)
+		if err != nil {
+			errs = append(errs, &NodeError{node, err})
+			continue
 		}
+
+		list = append(list, ref)
+	}
+
+	if len(errs) > 0 {
+		return list, errs
+	}
+
+	return list, nil
+}
+
+// eventNodes returns the event nodes in the given directory,
+// sorted by their numeric suffix. Gaps in the numbering are
+// irrelevant; whatever exists is returned.
+func eventNodes(dir string) ([]string, error) {
+	nodes, err := filepath.Glob(filepath.Join(dir, "event*"))
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Sort(byEventNumber(nodes))
+	return nodes, nil
+}
+
+// byEventNumber sorts node names such that event10 comes after event9.
+type byEventNumber []string
+
+func (s byEventNumber) Len() int      { return len(s) }
+func (s byEventNumber) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byEventNumber) Less(i, j int) bool {
+	a, aok := eventNumber(s[i])
+	b, bok := eventNumber(s[j])
+
+	if aok && bok && a != b {
+		return a < b
 	}
 
-	return
+	return s[i] < s[j]
+}
+
+// eventNumber returns the numeric suffix of the given event node name.
+func eventNumber(node string) (int, bool) {
+	n, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(node), "event"))
+	return n, err == nil
 }
 
 // IsKeyboard returns true if the given device qualifies as a keyboard.
-func IsKeyboard(dev *Device) bool {
-	return dev.Test(dev.EventTypes(), EvKeys, EvLed)
+func IsKeyboard(dev Capable) bool {
+	return testBits(dev.EventTypes(), EvKeys, EvLed)
 }
 
 // IsMouse returns true if the given device qualifies as a mouse.
-func IsMouse(dev *Device) bool {
-	return dev.Test(dev.EventTypes(), EvKeys, EvRelative)
+func IsMouse(dev Capable) bool {
+	return testBits(dev.EventTypes(), EvKeys, EvRelative)
 }
 
 // IsJoystick returns true if the given device qualifies as a joystick.
-func IsJoystick(dev *Device) bool {
-	return dev.Test(dev.EventTypes(), EvKeys, EvAbsolute)
+func IsJoystick(dev Capable) bool {
+	return testBits(dev.EventTypes(), EvKeys, EvAbsolute)
 }
@@ -0,0 +1,35 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestEventNodeOrder(t *testing.T) {
+	nodes := []string{
+		"/dev/input/event10",
+		"/dev/input/event2",
+		"/dev/input/event9",
+		"/dev/input/event0",
+		"/dev/input/event4",
+	}
+
+	want := []string{
+		"/dev/input/event0",
+		"/dev/input/event2",
+		"/dev/input/event4",
+		"/dev/input/event9",
+		"/dev/input/event10",
+	}
+
+	sort.Sort(byEventNumber(nodes))
+
+	for i := range want {
+		if nodes[i] != want[i] {
+			t.Fatalf("Index %d: Want %s, have %s", i, want[i], nodes[i])
+		}
+	}
+}
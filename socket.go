@@ -0,0 +1,106 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import (
+	"errors"
+	"net"
+	"os"
+	"syscall"
+)
+
+// maxNodeName is the maximum length of a node name passed
+// along with a file descriptor.
+const maxNodeName = 4096
+
+// ErrNoFile is returned by ReceiveFile if a message arrived
+// without a file descriptor attached.
+var ErrNoFile = errors.New("evdev: no file descriptor received")
+
+// SendFile passes an opened device node to another process over
+// a unix domain socket, using SCM_RIGHTS. The name of the file is
+// sent along with it.
+//
+// This allows a privileged process to open nodes in /dev/input
+// on behalf of a worker which has no access to them itself.
+// The caller remains responsible for closing its own copy of the file.
+func SendFile(conn *net.UnixConn, fd *os.File) error {
+	name := fd.Name()
+	if len(name) == 0 || len(name) > maxNodeName {
+		name = "?"
+	}
+
+	return control(fd, func(sysfd uintptr) error {
+		rights := syscall.UnixRights(int(sysfd))
+		_, _, err := conn.WriteMsgUnix([]byte(name), rights, nil)
+		return err
+	})
+}
+
+// SendDevice passes the node of an opened device to another process.
+// See SendFile.
+func SendDevice(conn *net.UnixConn, dev *Device) error {
+	return SendFile(conn, dev.fd)
+}
+
+// ReceiveFile receives a device node sent through SendFile.
+func ReceiveFile(conn *net.UnixConn) (*os.File, error) {
+	buf := make([]byte, maxNodeName)
+	oob := make([]byte, syscall.CmsgSpace(4))
+
+	n, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+	if err != nil {
+		return nil, err
+	}
+
+	msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	if err != nil {
+		return nil, err
+	}
+
+	var fds []int
+
+	for i := range msgs {
+		list, err := syscall.ParseUnixRights(&msgs[i])
+		if err == nil {
+			fds = append(fds, list...)
+		}
+	}
+
+	if len(fds) == 0 {
+		return nil, ErrNoFile
+	}
+
+	// We only expect one; do not leak any extras.
+	for _, fd := range fds[1:] {
+		syscall.Close(fd)
+	}
+
+	syscall.CloseOnExec(fds[0])
+	return os.NewFile(uintptr(fds[0]), string(buf[:n])), nil
+}
+
+// ReceiveDevice receives a device node sent through SendFile or
+// SendDevice and opens it as a device.
+func ReceiveDevice(conn *net.UnixConn) (*Device, error) {
+	return ReceiveDeviceWith(conn, Options{})
+}
+
+// ReceiveDeviceWith receives a device node sent through SendFile or
+// SendDevice and opens it as a device, using the given options.
+// See NewFromFileWith.
+func ReceiveDeviceWith(conn *net.UnixConn, opt Options) (*Device, error) {
+	fd, err := ReceiveFile(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	dev, err := NewFromFileWith(fd, opt)
+	if err != nil {
+		fd.Close()
+		return nil, err
+	}
+
+	return dev, nil
+}
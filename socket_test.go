@@ -0,0 +1,72 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestSendFile(t *testing.T) {
+	pair, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_STREAM, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := socketConn(t, pair[0])
+	defer a.Close()
+
+	b := socketConn(t, pair[1])
+	defer b.Close()
+
+	src, err := ioutil.TempFile("", "evdev")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Remove(src.Name())
+	defer src.Close()
+
+	if err = SendFile(a, src); err != nil {
+		t.Fatal(err)
+	}
+
+	dst, err := ReceiveFile(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer dst.Close()
+
+	if dst.Name() != src.Name() {
+		t.Fatalf("Name: Want %s, have %s", src.Name(), dst.Name())
+	}
+
+	// Both descriptors must refer to the same file.
+	want, _ := src.Stat()
+	have, _ := dst.Stat()
+	if !os.SameFile(want, have) {
+		t.Fatalf("Received descriptor refers to a different file")
+	}
+
+	// A regular file is not an evdev node.
+	if _, err = NewFromFile(dst); err != ErrNotEvdev {
+		t.Fatalf("NewFromFile: Want %v, have %v", ErrNotEvdev, err)
+	}
+}
+
+func socketConn(t *testing.T, fd int) *net.UnixConn {
+	f := os.NewFile(uintptr(fd), "socket")
+	defer f.Close()
+
+	conn, err := net.FileConn(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return conn.(*net.UnixConn)
+}
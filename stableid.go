@@ -0,0 +1,168 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// ErrNotFound is returned when a device can not be located.
+var ErrNotFound = errors.New("evdev: device not found")
+
+// StableID identifies a specific physical device, independent of the
+// /dev/input/event[X] node it happens to be mapped to. It remains
+// the same across reconnects and reboots and can therefore safely be
+// stored in configuration files.
+//
+// The naming mirrors the links udev creates in /dev/input/by-id and
+// /dev/input/by-path. It comes in one of three forms:
+//
+//	by-id:usb-046d_c52b-0123ABCD-input2-event-mouse
+//	by-path:usb-046d_c52b-usb-0000:00:14.0-2_input0-event-kbd
+//	by-name:virtual-0000_0000-My_Virtual_Device-event
+//
+// by-id is used when the device reports a serial code. It follows the
+// device when plugged into a different port. by-path is used when
+// there is no serial code, but there is a physical path. It identifies
+// the port the device is plugged into. by-name is the fallback for
+// devices which report neither, such as most virtual devices.
+type StableID string
+
+// StableID returns the stable identity for this device.
+func (d *Device) StableID() StableID {
+	return stableID(d.Id(), d.Name(), d.Serial(), d.Path(), d)
+}
+
+// StableID returns the stable identity for the referenced device.
+func (r *DeviceRef) StableID() StableID {
+	return stableID(r.Id, r.Name, r.Serial, r.Path, r)
+}
+
+// Resolve finds the device with the given stable identity and
+// returns a reference to its current node.
+//
+// If more than one device matches, the one with the lowest
+// event node number is returned. ErrNotFound is returned if
+// no device matches.
+func Resolve(id StableID) (*DeviceRef, error) {
+	refs, err := Enumerate()
+	if _, ok := err.(NodeErrors); err != nil && !ok {
+		return nil, err
+	}
+
+	for _, ref := range refs {
+		if ref.StableID() == id {
+			return ref, nil
+		}
+	}
+
+	// The device may be hiding behind one of the nodes we
+	// could not inspect. Let the caller know about them.
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, ErrNotFound
+}
+
+// stableID builds a stable identity from the given device information.
+func stableID(id Id, name, serial, phys string, dev Capable) StableID {
+	prefix := fmt.Sprintf("%s-%04x_%04x", busName(id.BusType), id.Vendor, id.Product)
+	class := eventClass(dev)
+
+	switch {
+	case len(serial) > 0:
+		// Several event nodes can share a serial code; one for each
+		// interface of a composite device. The last element of the
+		// physical path tells them apart.
+		s := "by-id:" + prefix + "-" + sanitizeID(serial)
+		if iface := path.Base(phys); len(phys) > 0 && iface != "." {
+			s += "-" + sanitizeID(iface)
+		}
+		return StableID(s + class)
+
+	case len(phys) > 0:
+		return StableID("by-path:" + prefix + "-" + sanitizeID(phys) + class)
+	}
+
+	return StableID("by-name:" + prefix + "-" + sanitizeID(name) + class)
+}
+
+// eventClass returns the suffix udev appends to its links,
+// describing the kind of device.
+func eventClass(dev Capable) string {
+	switch {
+	case IsKeyboard(dev):
+		return "-event-kbd"
+	case IsMouse(dev):
+		return "-event-mouse"
+	case IsJoystick(dev):
+		return "-event-joystick"
+	}
+
+	return "-event"
+}
+
+// sanitizeID replaces every character which udev would not allow
+// in a link name with an underscore.
+func sanitizeID(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= '0' && r <= '9', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+			return r
+		case strings.ContainsRune("#+-.:=@_", r):
+			return r
+		}
+		return '_'
+	}, strings.TrimSpace(s))
+}
+
+// busName returns a short name for the given BusXXX value.
+func busName(bus uint16) string {
+	switch bus {
+	case BusPCI:
+		return "pci"
+	case BusISAPNP:
+		return "isapnp"
+	case BusUSB:
+		return "usb"
+	case BusHIL:
+		return "hil"
+	case BusBluetooth:
+		return "bluetooth"
+	case BusVirtual:
+		return "virtual"
+	case BusISA:
+		return "isa"
+	case BusI8042:
+		return "i8042"
+	case BusXTKBD:
+		return "xtkbd"
+	case BusRS232:
+		return "rs232"
+	case BusGamePort:
+		return "gameport"
+	case BusParPort:
+		return "parport"
+	case BusAmiga:
+		return "amiga"
+	case BusADB:
+		return "adb"
+	case BusI2C:
+		return "i2c"
+	case BusHost:
+		return "host"
+	case BusGSC:
+		return "gsc"
+	case BusAtari:
+		return "atari"
+	case BusSPI:
+		return "spi"
+	}
+
+	return fmt.Sprintf("bus%02x", bus)
+}
@@ -0,0 +1,54 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import (
+	"testing"
+)
+
+type testCaps Bitset
+
+func (c testCaps) EventTypes() Bitset { return Bitset(c) }
+
+func TestStableID(t *testing.T) {
+	kbd := NewBitset(EvCount)
+	kbd.Set(EvKeys)
+	kbd.Set(EvLed)
+
+	usb := Id{BusType: BusUSB, Vendor: 0x046d, Product: 0xc52b, Version: 0x111}
+
+	want := []struct {
+		Id     Id
+		Name   string
+		Serial string
+		Phys   string
+		Caps   Bitset
+		Value  StableID
+	}{
+		{usb, "Logitech USB Receiver", "0123ABCD", "usb-0000:00:14.0-2/input2", kbd,
+			"by-id:usb-046d_c52b-0123ABCD-input2-event-kbd"},
+		{usb, "Logitech USB Receiver", "", "usb-0000:00:14.0-2/input0", kbd,
+			"by-path:usb-046d_c52b-usb-0000:00:14.0-2_input0-event-kbd"},
+		{Id{BusType: BusVirtual}, "My Virtual Device", "", "", NewBitset(EvCount),
+			"by-name:virtual-0000_0000-My_Virtual_Device-event"},
+	}
+
+	for i, w := range want {
+		have := stableID(w.Id, w.Name, w.Serial, w.Phys, testCaps(w.Caps))
+		if have != w.Value {
+			t.Fatalf("Index %d: Want %s, have %s", i, w.Value, have)
+		}
+	}
+
+	// The version number must not matter; it can change with
+	// a firmware update.
+	usb2 := usb
+	usb2.Version = 0x222
+
+	a := stableID(usb, "", "X", "p/input0", testCaps(kbd))
+	b := stableID(usb2, "", "X", "p/input0", testCaps(kbd))
+	if a != b {
+		t.Fatalf("Version changes identity: %s != %s", a, b)
+	}
+}
@@ -3,6 +3,8 @@
 
 package evdev
 
+import "unsafe"
+
 // Switch events describe stateful binary switches. For example,
 // the SwLid code is used to denote when a laptop lid is closed.
 //
@@ -29,6 +31,19 @@ const (
 	SwFrontProximity     = 0x0b        // set = front proximity sensor active
 	SwRotateLock         = 0x0c        // set = rotate locked/disabled
 	SwLineInInsert       = 0x0d        // set = inserted
-	SwMax                = 0x0f
+	SwMuteDevice         = 0x0e        // set = device disabled
+	SwPenInserted        = 0x0f        // set = pen inserted
+	SwMachineCover       = 0x10        // set = cover closed
+	SwMax                = 0x10
 	SwCount              = SwMax + 1
 )
+
+// SwitchState returns the current state of the device's switches.
+//
+// This is only applicable to devices with EvSwitch event support.
+func (d *Device) SwitchState() Bitset {
+	bs := NewBitset(SwCount)
+	buf := bs.Bytes()
+	d.ioctl(_EVIOCGSW(len(buf)), unsafe.Pointer(&buf[0]))
+	return bs
+}
@@ -0,0 +1,116 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+func TestSwitchBits(t *testing.T) {
+	// Buffers as filled in by EVIOCGSW: bit n of byte n/8 is switch n.
+	tests := []struct {
+		buf  []byte
+		want []int
+	}{
+		{[]byte{0x00, 0x00, 0x00}, nil},
+		{[]byte{0x01, 0x00, 0x00}, []int{SwLid}},
+		{[]byte{0x06, 0x00, 0x00}, []int{SwTabletMode, SwHeadphoneInsert}},
+		{[]byte{0x00, 0x81, 0x00}, []int{SwVideoOutInsert, SwPenInserted}},
+		{[]byte{0x20, 0x00, 0x01}, []int{SwDock, SwMachineCover}},
+	}
+
+	for i, tt := range tests {
+		bs := NewBitset(SwCount)
+		copy(bs.Bytes(), tt.buf)
+
+		var have []int
+		for code := 0; code < SwCount; code++ {
+			if bs.Test(code) {
+				have = append(have, code)
+			}
+		}
+
+		if !reflect.DeepEqual(have, tt.want) {
+			t.Fatalf("Index %d: Want %v, have %v", i, tt.want, have)
+		}
+	}
+}
+
+func TestInitialSwitches(t *testing.T) {
+	tests := []struct {
+		switches map[int]bool
+		want     []Event
+	}{
+		{nil, nil},
+		{map[int]bool{SwLid: false, SwDock: false}, nil},
+		{
+			map[int]bool{SwDock: true, SwLid: true, SwTabletMode: false},
+			[]Event{
+				{Type: EvSwitch, Code: SwLid, Value: 1},
+				{Type: EvSwitch, Code: SwDock, Value: 1},
+				{Type: EvSync, Code: SynReport},
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		if have := initialSwitches(tt.switches); !reflect.DeepEqual(have, tt.want) {
+			t.Fatalf("Index %d: Want %v, have %v", i, tt.want, have)
+		}
+	}
+}
+
+func TestVirtualSwitchSet(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer r.Close()
+
+	s := &VirtualSwitch{
+		VirtualDevice: &VirtualDevice{fd: w},
+		state:         map[int]bool{SwLid: false, SwDock: true},
+	}
+
+	defer s.Close()
+
+	if err = s.Set(SwTabletMode, true); err == nil {
+		t.Fatalf("Set: Expected error for unsupported switch")
+	}
+
+	// Neither changes the state; nothing is sent.
+	s.Set(SwLid, false)
+	s.Set(SwDock, true)
+
+	s.Set(SwLid, true)
+	s.Set(SwDock, false)
+
+	want := []Event{
+		{Type: EvSwitch, Code: SwLid, Value: 1},
+		{Type: EvSync, Code: SynReport},
+		{Type: EvSwitch, Code: SwDock, Value: 0},
+		{Type: EvSync, Code: SynReport},
+	}
+
+	have := make([]Event, 8)
+	size := int(unsafe.Sizeof(have[0]))
+	raw := unsafe.Slice((*byte)(unsafe.Pointer(&have[0])), len(have)*size)
+
+	n, err := r.Read(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have = have[:n/size]; !reflect.DeepEqual(have, want) {
+		t.Fatalf("Events: Want %v, have %v", want, have)
+	}
+
+	if !s.State(SwLid) || s.State(SwDock) {
+		t.Fatalf("State: Want lid set and dock unset")
+	}
+}
@@ -0,0 +1,136 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import "fmt"
+
+// VirtualSwitch is a virtual device with stateful binary switches,
+// such as a laptop lid (SwLid) or a headphone jack (SwHeadphoneInsert).
+type VirtualSwitch struct {
+	*VirtualDevice
+	state map[int]bool
+}
+
+// NewVirtualSwitch creates a virtual switch device with the given name.
+// The switches map holds the switches (SwXXX) the device has, along with
+// their initial state. Switches which start out set, are reported right
+// after the device has been created; the kernel assumes all switches
+// to be unset before that.
+func NewVirtualSwitch(name string, switches map[int]bool) (*VirtualSwitch, error) {
+	sw := NewBitset(SwCount)
+	for code := range switches {
+		if code < 0 || code > SwMax {
+			return nil, fmt.Errorf("uinput: invalid switch %#x", code)
+		}
+
+		sw.Set(code)
+	}
+
+	dev, err := CreateVirtual(VirtualConfig{
+		Name:         name,
+		Id:           Id{BusType: BusVirtual},
+		Capabilities: map[int]Bitset{EvSwitch: sw},
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	s := &VirtualSwitch{
+		VirtualDevice: dev,
+		state:         make(map[int]bool),
+	}
+
+	for code, on := range switches {
+		s.state[code] = on
+	}
+
+	if list := initialSwitches(switches); len(list) > 0 {
+		if err = dev.WriteEvents(list...); err != nil {
+			dev.Close()
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// initialSwitches returns the frame which reports the switches
+// which start out set, or nil if there are none.
+func initialSwitches(switches map[int]bool) []Event {
+	var list []Event
+	for _, code := range sortedKeys(switches) {
+		if switches[code] {
+			list = append(list, switchEvent(code, true))
+		}
+	}
+
+	if len(list) == 0 {
+		return nil
+	}
+
+	return append(list, Event{Type: EvSync, Code: SynReport})
+}
+
+// switchEvent returns an event which sets or unsets the given switch.
+func switchEvent(code int, on bool) Event {
+	var v int32
+	if on {
+		v = 1
+	}
+
+	return Event{Type: EvSwitch, Code: uint16(code), Value: v}
+}
+
+// Set sets or unsets the given switch. The kernel drops reports which do
+// not change the state of a switch, so setting a switch to the state it
+// already has, sends nothing.
+func (s *VirtualSwitch) Set(code int, on bool) error {
+	cur, ok := s.state[code]
+	if !ok {
+		return fmt.Errorf("uinput: switch %#x not supported", code)
+	}
+
+	if cur == on {
+		return nil
+	}
+
+	err := s.WriteEvents(
+		switchEvent(code, on),
+		Event{Type: EvSync, Code: SynReport},
+	)
+
+	if err == nil {
+		s.state[code] = on
+	}
+
+	return err
+}
+
+// State returns the state of the given switch, as last set.
+func (s *VirtualSwitch) State(code int) bool {
+	return s.state[code]
+}
+
+// ReadState reads the state of the switches back from the event node
+// the kernel created for the device. This is the state as the rest of
+// the system sees it. See Device.SwitchState.
+func (s *VirtualSwitch) ReadState() (Bitset, error) {
+	nodes, err := s.Nodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(nodes) == 0 {
+		return nil, ErrNotFound
+	}
+
+	dev, err := OpenWith(nodes[0], Options{ReadOnly: true, NoGoroutines: true})
+	if err != nil {
+		return nil, err
+	}
+
+	defer dev.Close()
+	return dev.SwitchState(), nil
+}
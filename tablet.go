@@ -0,0 +1,356 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// ErrNoTool is returned when a VirtualTablet is asked to report
+// tool input, while no tool is in proximity.
+var ErrNoTool = errors.New("evdev: no tool in proximity")
+
+// TabletTool describes a tool used on a VirtualTablet.
+type TabletTool struct {
+	Type   int   // Tool type; BtnToolPen or BtnTooLRubber.
+	Id     int32 // Tool id, as reported through AbsMisc.
+	Serial int32 // Tool serial number, as reported through MiscSerial.
+}
+
+// Predefined tablet tools. These are the tip and eraser of a Wacom
+// Grip Pen. They share their serial number, as they are two ends
+// of the same physical pen.
+var (
+	TabletPen    = TabletTool{Type: BtnToolPen, Id: 0x802, Serial: 1}
+	TabletEraser = TabletTool{Type: BtnTooLRubber, Id: 0x80a, Serial: 1}
+)
+
+// TabletPoint describes the state of a tool at a single point of a stroke.
+type TabletPoint struct {
+	X, Y int // Position in device units.
+
+	// Pressure is in the range [0, 1]. The tool touches the
+	// tablet as long as it is not 0.
+	Pressure float64
+
+	// Distance is the hover distance, in the range [0, 1],
+	// where 0 is closest. It is ignored while the tool touches
+	// the tablet.
+	Distance float64
+
+	// TiltX and TiltY are in degrees, in the range [-64, 63].
+	// Positive values tilt to the right and towards the user.
+	TiltX, TiltY float64
+}
+
+// TabletConfig describes a VirtualTablet.
+type TabletConfig struct {
+	Name string // Device name.
+	Id   Id     // Device identity.
+
+	Width  int // Width of the active area, in device units.
+	Height int // Height of the active area, in device units.
+
+	// Resolution is the number of device units per millimeter.
+	// libinput ignores tablets without one. It defaults to 100.
+	Resolution int
+
+	// PressureLevels is the number of distinct pressure values.
+	// It defaults to 2048.
+	PressureLevels int
+
+	// Direct marks the tablet as being part of a screen,
+	// rather than an external one.
+	Direct bool
+}
+
+// Axis ranges used by VirtualTablet. These match those of Wacom devices.
+const (
+	tabletDistanceMax = 63
+	tabletTiltMin     = -64
+	tabletTiltMax     = 63
+	tabletTiltRes     = 57 // Units per radian; about one per degree.
+)
+
+// VirtualTablet is a virtual pen tablet. It reports tool input
+// the way the Wacom kernel driver does, which is what libinput and
+// applications expect from a tablet.
+//
+// A tool first comes into proximity (ProximityIn), then hovers or
+// touches the tablet (Move), and finally leaves again (ProximityOut).
+type VirtualTablet struct {
+	*VirtualDevice
+
+	// Interval is the time between two points of a stroke.
+	// It defaults to DefaultMotionInterval.
+	Interval time.Duration
+
+	levels   int
+	tool     *TabletTool // Tool in proximity, if any.
+	touching bool
+}
+
+// NewVirtualTablet creates a virtual tablet with the given configuration.
+func NewVirtualTablet(cfg TabletConfig) (*VirtualTablet, error) {
+	if cfg.Resolution <= 0 {
+		cfg.Resolution = 100
+	}
+
+	if cfg.PressureLevels <= 0 {
+		cfg.PressureLevels = 2048
+	}
+
+	if cfg.Id.BusType == 0 {
+		cfg.Id.BusType = BusVirtual
+	}
+
+	keys := NewBitset(KeyCount)
+	keys.Set(BtnToolPen)
+	keys.Set(BtnTooLRubber)
+	keys.Set(BtnTouch)
+	keys.Set(BtnStylus)
+	keys.Set(BtnStylus2)
+
+	misc := NewBitset(MiscCount)
+	misc.Set(MiscSerial)
+
+	props := NewBitset(InputPropCount)
+	if cfg.Direct {
+		props.Set(InputPropDirect)
+	} else {
+		props.Set(InputPropPointer)
+	}
+
+	tilt := AbsInfo{Minimum: tabletTiltMin, Maximum: tabletTiltMax, Resolution: tabletTiltRes}
+
+	dev, err := CreateVirtual(VirtualConfig{
+		Name:       cfg.Name,
+		Id:         cfg.Id,
+		Properties: props,
+		Capabilities: map[int]Bitset{
+			EvKeys: keys,
+			EvMisc: misc,
+		},
+		Absolute: map[int]AbsInfo{
+			AbsX:        {Maximum: int32(cfg.Width - 1), Resolution: int32(cfg.Resolution)},
+			AbsY:        {Maximum: int32(cfg.Height - 1), Resolution: int32(cfg.Resolution)},
+			AbsPressure: {Maximum: int32(cfg.PressureLevels - 1)},
+			AbsDistance: {Maximum: tabletDistanceMax},
+			AbsTiltX:    tilt,
+			AbsTiltY:    tilt,
+			AbsMisc:     {Maximum: math.MaxInt32},
+		},
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	t := newTablet(cfg.PressureLevels)
+	t.VirtualDevice = dev
+	return t, nil
+}
+
+// newTablet creates the state for a tablet
+// with the given number of pressure levels.
+func newTablet(levels int) *VirtualTablet {
+	return &VirtualTablet{
+		Interval: DefaultMotionInterval,
+		levels:   levels,
+	}
+}
+
+// ProximityIn brings the given tool into proximity, at the given point.
+// If another tool is in proximity, it is taken out first.
+func (t *VirtualTablet) ProximityIn(tool TabletTool, p TabletPoint) error {
+	if t.tool != nil {
+		if err := t.ProximityOut(); err != nil {
+			return err
+		}
+	}
+
+	return t.frame(t.proximityIn(tool, p))
+}
+
+// Move moves the tool in proximity to the given point. The tool
+// touches down or lifts off, as the pressure becomes non-zero or zero.
+func (t *VirtualTablet) Move(p TabletPoint) error {
+	if t.tool == nil {
+		return ErrNoTool
+	}
+
+	return t.frame(t.move(p))
+}
+
+// ProximityOut takes the tool in proximity away from the tablet,
+// lifting it off first if necessary.
+func (t *VirtualTablet) ProximityOut() error {
+	if t.tool == nil {
+		return nil
+	}
+
+	if t.touching {
+		if err := t.frame(t.lift()); err != nil {
+			return err
+		}
+	}
+
+	return t.frame(t.proximityOut())
+}
+
+// SetButton presses or releases one of the stylus buttons
+// (BtnStylus, BtnStylus2) of the tool in proximity.
+func (t *VirtualTablet) SetButton(btn int, pressed bool) error {
+	if t.tool == nil {
+		return ErrNoTool
+	}
+
+	var v int32
+	if pressed {
+		v = 1
+	}
+
+	return t.frame([]Event{
+		{Type: EvKeys, Code: uint16(btn), Value: v},
+		t.serial(),
+	})
+}
+
+// Stroke replays the given points with the given tool. The tool comes
+// into proximity at the first point and goes out of proximity after
+// the last one. Points are Interval apart in time.
+func (t *VirtualTablet) Stroke(tool TabletTool, points []TabletPoint) error {
+	if len(points) == 0 {
+		return nil
+	}
+
+	start := points[0]
+	start.Pressure = 0
+
+	err := t.ProximityIn(tool, start)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range points {
+		time.Sleep(t.Interval)
+
+		if err = t.Move(p); err != nil {
+			return err
+		}
+	}
+
+	time.Sleep(t.Interval)
+	return t.ProximityOut()
+}
+
+// proximityIn returns the events which bring the given tool into proximity.
+func (t *VirtualTablet) proximityIn(tool TabletTool, p TabletPoint) []Event {
+	t.tool = &tool
+	t.touching = false
+
+	hover := p
+	hover.Pressure = 0
+
+	list := t.axes(hover)
+	list = append(list,
+		Event{Type: EvAbsolute, Code: AbsMisc, Value: tool.Id},
+		Event{Type: EvKeys, Code: uint16(tool.Type), Value: 1},
+		t.serial(),
+	)
+
+	// Real tablets report the tool before it touches down;
+	// never in the same frame.
+	if p.Pressure > 0 {
+		list = append(list, Event{Type: EvSync, Code: SynReport})
+		list = append(list, t.move(p)...)
+	}
+
+	return list
+}
+
+// move returns the events which move the tool to the given point.
+func (t *VirtualTablet) move(p TabletPoint) []Event {
+	list := t.axes(p)
+
+	if touching := p.Pressure > 0; touching != t.touching {
+		t.touching = touching
+
+		var v int32
+		if touching {
+			v = 1
+		}
+
+		list = append(list, Event{Type: EvKeys, Code: BtnTouch, Value: v})
+	}
+
+	return append(list, t.serial())
+}
+
+// lift returns the events which lift the tool off the tablet.
+func (t *VirtualTablet) lift() []Event {
+	t.touching = false
+
+	return []Event{
+		{Type: EvAbsolute, Code: AbsPressure, Value: 0},
+		{Type: EvKeys, Code: BtnTouch, Value: 0},
+		t.serial(),
+	}
+}
+
+// proximityOut returns the events which take the tool out of proximity.
+// All axes are reset, as the Wacom driver does.
+func (t *VirtualTablet) proximityOut() []Event {
+	list := []Event{
+		{Type: EvAbsolute, Code: AbsX, Value: 0},
+		{Type: EvAbsolute, Code: AbsY, Value: 0},
+		{Type: EvAbsolute, Code: AbsDistance, Value: 0},
+		{Type: EvAbsolute, Code: AbsTiltX, Value: 0},
+		{Type: EvAbsolute, Code: AbsTiltY, Value: 0},
+		{Type: EvAbsolute, Code: AbsMisc, Value: 0},
+		{Type: EvKeys, Code: uint16(t.tool.Type), Value: 0},
+		t.serial(),
+	}
+
+	t.tool = nil
+	return list
+}
+
+// axes returns the axis events for the given point.
+func (t *VirtualTablet) axes(p TabletPoint) []Event {
+	pressure := clamp(p.Pressure, 0, 1) * float64(t.levels-1)
+
+	distance := 0.0
+	if p.Pressure <= 0 {
+		distance = clamp(p.Distance, 0, 1) * tabletDistanceMax
+	}
+
+	return []Event{
+		{Type: EvAbsolute, Code: AbsX, Value: int32(p.X)},
+		{Type: EvAbsolute, Code: AbsY, Value: int32(p.Y)},
+		{Type: EvAbsolute, Code: AbsPressure, Value: int32(math.Round(pressure))},
+		{Type: EvAbsolute, Code: AbsDistance, Value: int32(math.Round(distance))},
+		{Type: EvAbsolute, Code: AbsTiltX, Value: int32(math.Round(clamp(p.TiltX, tabletTiltMin, tabletTiltMax)))},
+		{Type: EvAbsolute, Code: AbsTiltY, Value: int32(math.Round(clamp(p.TiltY, tabletTiltMin, tabletTiltMax)))},
+	}
+}
+
+// serial returns the serial number event of the tool in proximity.
+// The Wacom driver sends one with every frame.
+func (t *VirtualTablet) serial() Event {
+	return Event{Type: EvMisc, Code: MiscSerial, Value: t.tool.Serial}
+}
+
+// frame sends the given events, followed by a SynReport.
+func (t *VirtualTablet) frame(list []Event) error {
+	list = append(list, Event{Type: EvSync, Code: SynReport})
+	return t.WriteEvents(list...)
+}
+
+// clamp limits v to the range [min, max].
+func clamp(v, min, max float64) float64 {
+	return math.Max(min, math.Min(max, v))
+}
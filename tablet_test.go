@@ -0,0 +1,63 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTabletFrames(t *testing.T) {
+	tab := newTablet(1024)
+
+	abs := func(code int, v int32) Event {
+		return Event{Type: EvAbsolute, Code: uint16(code), Value: v}
+	}
+
+	key := func(code int, v int32) Event {
+		return Event{Type: EvKeys, Code: uint16(code), Value: v}
+	}
+
+	serial := Event{Type: EvMisc, Code: MiscSerial, Value: TabletPen.Serial}
+	syn := Event{Type: EvSync, Code: SynReport}
+
+	got := tab.proximityIn(TabletPen, TabletPoint{X: 10, Y: 20, Pressure: 0.5, Distance: 0.5, TiltX: 100})
+	want := []Event{
+		abs(AbsX, 10), abs(AbsY, 20), abs(AbsPressure, 0), abs(AbsDistance, 32),
+		abs(AbsTiltX, 63), abs(AbsTiltY, 0), abs(AbsMisc, TabletPen.Id), key(BtnToolPen, 1), serial,
+		syn,
+		abs(AbsX, 10), abs(AbsY, 20), abs(AbsPressure, 512), abs(AbsDistance, 0),
+		abs(AbsTiltX, 63), abs(AbsTiltY, 0), key(BtnTouch, 1), serial,
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Proximity in:\nWant %v\nhave %v", want, got)
+	}
+
+	got = tab.move(TabletPoint{X: 11, Y: 20, Pressure: 1})
+	want = []Event{
+		abs(AbsX, 11), abs(AbsY, 20), abs(AbsPressure, 1023), abs(AbsDistance, 0),
+		abs(AbsTiltX, 0), abs(AbsTiltY, 0), serial,
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Move:\nWant %v\nhave %v", want, got)
+	}
+
+	got = tab.lift()
+	want = []Event{abs(AbsPressure, 0), key(BtnTouch, 0), serial}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Lift:\nWant %v\nhave %v", want, got)
+	}
+
+	got = tab.proximityOut()
+	if last := got[len(got)-2]; last != key(BtnToolPen, 0) {
+		t.Fatalf("Proximity out: Want tool release, have %v", last)
+	}
+
+	if tab.tool != nil {
+		t.Fatalf("Proximity out: Tool still in proximity")
+	}
+}
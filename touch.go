@@ -0,0 +1,396 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ErrNoSlot is returned when a contact is placed on a touchscreen
+// which has no free slots left.
+var ErrNoSlot = errors.New("evdev: no free touch slot")
+
+// DefaultTapDuration is the default time a contact stays down
+// when tapping a VirtualTouchscreen.
+const DefaultTapDuration = 50 * time.Millisecond
+
+// VirtualTouchscreen is a virtual multitouch device, which reports its
+// contacts through protocol B: each contact occupies a slot for as
+// long as it is down, and is identified by a unique tracking id.
+// The position of the first contact is also reported through AbsX
+// and AbsY, for applications which only understand single touch.
+//
+// Coordinates are in device units, in the range of the width and height
+// given to NewVirtualTouchscreen. The receiving end maps them onto the
+// screen.
+type VirtualTouchscreen struct {
+	*VirtualDevice
+
+	// Interval is the time between two frames of a gesture.
+	// It defaults to DefaultMotionInterval.
+	Interval time.Duration
+
+	// Hold is the time a contact stays down when tapping.
+	// It defaults to DefaultTapDuration.
+	Hold time.Duration
+
+	slots   []touchSlot
+	slot    int     // Currently selected slot.
+	nextId  int32   // Next tracking id.
+	touch   bool    // Whether BtnTouch is currently down.
+	pending []Event // Events of the frame being built.
+}
+
+// touchSlot holds the state of a single contact.
+type touchSlot struct {
+	active bool
+	x, y   int
+}
+
+// TouchPressure is the pressure reported for contacts,
+// on a scale of 0-255.
+const TouchPressure = 128
+
+// NewVirtualTouchscreen creates a virtual touchscreen with the given name
+// and size in device units, which can track up to the given number of
+// simultaneous contacts.
+func NewVirtualTouchscreen(name string, width, height, slots int) (*VirtualTouchscreen, error) {
+	if slots < 1 {
+		slots = 1
+	}
+
+	keys := NewBitset(KeyCount)
+	keys.Set(BtnTouch)
+
+	props := NewBitset(InputPropCount)
+	props.Set(InputPropDirect)
+
+	x := AbsInfo{Maximum: int32(width - 1)}
+	y := AbsInfo{Maximum: int32(height - 1)}
+
+	dev, err := CreateVirtual(VirtualConfig{
+		Name:         name,
+		Id:           Id{BusType: BusVirtual},
+		Properties:   props,
+		Capabilities: map[int]Bitset{EvKeys: keys},
+		Absolute: map[int]AbsInfo{
+			AbsX:            x,
+			AbsY:            y,
+			AbsMTSlot:       {Maximum: int32(slots - 1)},
+			AbsMTTrackingId: {Maximum: 0xffff},
+			AbsMTPositionX:  x,
+			AbsMTPositionY:  y,
+			AbsMTPressure:   {Maximum: 255},
+		},
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	t := newTouchscreen(slots)
+	t.VirtualDevice = dev
+	return t, nil
+}
+
+// newTouchscreen creates the state for a touchscreen
+// with the given number of slots.
+func newTouchscreen(slots int) *VirtualTouchscreen {
+	return &VirtualTouchscreen{
+		Interval: DefaultMotionInterval,
+		Hold:     DefaultTapDuration,
+		slots:    make([]touchSlot, slots),
+	}
+}
+
+// Down places a new contact at the given position. It returns the
+// slot occupied by the contact, which identifies it in calls to
+// Move and Up.
+func (t *VirtualTouchscreen) Down(x, y int) (int, error) {
+	slot, err := t.down(x, y)
+	if err != nil {
+		return 0, err
+	}
+
+	return slot, t.flush()
+}
+
+// Move moves the contact in the given slot to the given position.
+func (t *VirtualTouchscreen) Move(slot, x, y int) error {
+	if err := t.checkSlot(slot); err != nil {
+		return err
+	}
+
+	t.move(slot, x, y)
+	return t.flush()
+}
+
+// Up lifts the contact in the given slot.
+func (t *VirtualTouchscreen) Up(slot int) error {
+	if err := t.checkSlot(slot); err != nil {
+		return err
+	}
+
+	t.up(slot)
+	return t.flush()
+}
+
+// Tap touches the given position briefly.
+func (t *VirtualTouchscreen) Tap(x, y int) error {
+	return t.LongPress(x, y, t.Hold)
+}
+
+// LongPress touches the given position for the given duration.
+func (t *VirtualTouchscreen) LongPress(x, y int, d time.Duration) error {
+	slot, err := t.Down(x, y)
+	if err != nil {
+		return err
+	}
+
+	time.Sleep(d)
+	return t.Up(slot)
+}
+
+// Swipe moves a single contact from (x0, y0) to (x1, y1) over the
+// given duration. The curve determines how the speed changes along
+// the way; nil means CurveLinear.
+func (t *VirtualTouchscreen) Swipe(x0, y0, x1, y1 int, d time.Duration, c Curve) error {
+	return t.Gesture([][2]int{{x0, y0}}, d, c, func(pos float64, i int) (int, int) {
+		return x0 + int(math.Round(pos*float64(x1-x0))), y0 + int(math.Round(pos*float64(y1-y0)))
+	})
+}
+
+// Pinch places two contacts on opposite sides of the given center,
+// at distance r0 from it, and moves them to distance r1 over the given
+// duration. Pinching in (r1 < r0) zooms out; pinching out zooms in.
+func (t *VirtualTouchscreen) Pinch(cx, cy, r0, r1 int, d time.Duration, c Curve) error {
+	return t.twoFingers(cx, cy, d, c, func(pos float64) (float64, float64) {
+		return float64(r0) + pos*float64(r1-r0), 0
+	})
+}
+
+// Rotate places two contacts on opposite sides of the given center,
+// at distance r from it, and rotates them around it by the given number
+// of degrees over the given duration. Positive angles rotate clockwise.
+func (t *VirtualTouchscreen) Rotate(cx, cy, r int, degrees float64, d time.Duration, c Curve) error {
+	return t.twoFingers(cx, cy, d, c, func(pos float64) (float64, float64) {
+		return float64(r), pos * degrees * math.Pi / 180
+	})
+}
+
+// twoFingers performs a gesture with two opposing contacts around the
+// given center. fn yields their distance from the center and angle,
+// for the given progress of the gesture.
+func (t *VirtualTouchscreen) twoFingers(cx, cy int, d time.Duration, c Curve, fn func(float64) (float64, float64)) error {
+	at := func(pos float64, i int) (int, int) {
+		r, angle := fn(pos)
+		if i == 1 {
+			angle += math.Pi
+		}
+
+		return orbit(cx, cy, r, angle)
+	}
+
+	start := make([][2]int, 2)
+	for i := range start {
+		start[i][0], start[i][1] = at(0, i)
+	}
+
+	return t.Gesture(start, d, c, at)
+}
+
+// Gesture performs a custom gesture. It places a contact at each of
+// the given starting positions, moves them over the given duration,
+// and then lifts them. fn yields the position of the i'th contact,
+// for the given progress of the gesture in the range (0, 1].
+// Every step of the gesture is sent as a single frame.
+func (t *VirtualTouchscreen) Gesture(start [][2]int, d time.Duration, c Curve, fn func(pos float64, i int) (int, int)) error {
+	free := 0
+	for _, s := range t.slots {
+		if !s.active {
+			free++
+		}
+	}
+
+	if free < len(start) {
+		return ErrNoSlot
+	}
+
+	slots := make([]int, len(start))
+	for i, p := range start {
+		slots[i], _ = t.down(p[0], p[1])
+	}
+
+	err := t.flush()
+	if err != nil {
+		return err
+	}
+
+	if c == nil {
+		c = CurveLinear
+	}
+
+	steps := motionSteps(d, t.Interval)
+
+	for step := 1; step <= steps; step++ {
+		time.Sleep(t.Interval)
+
+		pos := 1.0
+		if step < steps {
+			pos = c(float64(step) / float64(steps))
+		}
+
+		for i, slot := range slots {
+			x, y := fn(pos, i)
+			t.move(slot, x, y)
+		}
+
+		if err = t.flush(); err != nil {
+			break
+		}
+	}
+
+	for _, slot := range slots {
+		t.up(slot)
+	}
+
+	if ferr := t.flush(); err == nil {
+		err = ferr
+	}
+
+	return err
+}
+
+// checkSlot returns an error if the touchscreen has no such slot.
+func (t *VirtualTouchscreen) checkSlot(slot int) error {
+	if slot < 0 || slot >= len(t.slots) {
+		return fmt.Errorf("evdev: invalid touch slot %d", slot)
+	}
+
+	return nil
+}
+
+// down adds a new contact to the pending frame.
+func (t *VirtualTouchscreen) down(x, y int) (int, error) {
+	for i := range t.slots {
+		if t.slots[i].active {
+			continue
+		}
+
+		t.selectSlot(i)
+		t.abs(AbsMTTrackingId, t.nextId)
+		t.nextId = (t.nextId + 1) & 0xffff
+
+		t.slots[i] = touchSlot{active: true, x: -1, y: -1}
+		t.move(i, x, y)
+		t.abs(AbsMTPressure, TouchPressure)
+
+		return i, nil
+	}
+
+	return 0, ErrNoSlot
+}
+
+// move adds the movement of a contact to the pending frame.
+// Coordinates which did not change are left out.
+func (t *VirtualTouchscreen) move(slot, x, y int) {
+	s := &t.slots[slot]
+	if !s.active || (s.x == x && s.y == y) {
+		return
+	}
+
+	t.selectSlot(slot)
+
+	if s.x != x {
+		s.x = x
+		t.abs(AbsMTPositionX, int32(x))
+	}
+
+	if s.y != y {
+		s.y = y
+		t.abs(AbsMTPositionY, int32(y))
+	}
+}
+
+// up adds the lifting of a contact to the pending frame.
+func (t *VirtualTouchscreen) up(slot int) {
+	if !t.slots[slot].active {
+		return
+	}
+
+	t.selectSlot(slot)
+	t.abs(AbsMTTrackingId, -1)
+	t.slots[slot] = touchSlot{}
+}
+
+// selectSlot makes the given slot the target of subsequent events.
+func (t *VirtualTouchscreen) selectSlot(slot int) {
+	if t.slot != slot {
+		t.slot = slot
+		t.abs(AbsMTSlot, int32(slot))
+	}
+}
+
+// abs adds an absolute axis event to the pending frame.
+func (t *VirtualTouchscreen) abs(code int, value int32) {
+	t.pending = append(t.pending, Event{Type: EvAbsolute, Code: uint16(code), Value: value})
+}
+
+// frame completes the pending frame with BtnTouch and the single touch
+// position, as the kernel does for real devices, and returns it.
+func (t *VirtualTouchscreen) frame() []Event {
+	list := t.pending
+	t.pending = nil
+
+	var first *touchSlot
+	for i := range t.slots {
+		if t.slots[i].active {
+			first = &t.slots[i]
+			break
+		}
+	}
+
+	if touch := first != nil; touch != t.touch {
+		t.touch = touch
+
+		var v int32
+		if touch {
+			v = 1
+		}
+
+		list = append(list, Event{Type: EvKeys, Code: BtnTouch, Value: v})
+	}
+
+	if first != nil {
+		list = append(list,
+			Event{Type: EvAbsolute, Code: AbsX, Value: int32(first.x)},
+			Event{Type: EvAbsolute, Code: AbsY, Value: int32(first.y)},
+		)
+	}
+
+	if len(list) == 0 {
+		return nil
+	}
+
+	return append(list, Event{Type: EvSync, Code: SynReport})
+}
+
+// flush sends the pending frame.
+func (t *VirtualTouchscreen) flush() error {
+	list := t.frame()
+	if len(list) == 0 {
+		return nil
+	}
+
+	return t.WriteEvents(list...)
+}
+
+// orbit returns the point at the given distance and angle
+// (in radians) from the given center.
+func orbit(cx, cy int, r, angle float64) (int, int) {
+	return cx + int(math.Round(r*math.Cos(angle))), cy + int(math.Round(r*math.Sin(angle)))
+}
@@ -0,0 +1,79 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTouchFrames(t *testing.T) {
+	ts := newTouchscreen(2)
+
+	abs := func(code int, v int32) Event {
+		return Event{Type: EvAbsolute, Code: uint16(code), Value: v}
+	}
+
+	btn := func(v int32) Event {
+		return Event{Type: EvKeys, Code: BtnTouch, Value: v}
+	}
+
+	syn := Event{Type: EvSync, Code: SynReport}
+
+	tests := []struct {
+		fn   func()
+		want []Event
+	}{
+		{
+			func() { ts.down(10, 20) },
+			[]Event{
+				abs(AbsMTTrackingId, 0), abs(AbsMTPositionX, 10), abs(AbsMTPositionY, 20),
+				abs(AbsMTPressure, TouchPressure), btn(1), abs(AbsX, 10), abs(AbsY, 20), syn,
+			},
+		},
+		{
+			func() { ts.down(30, 40); ts.move(0, 11, 20) },
+			[]Event{
+				abs(AbsMTSlot, 1), abs(AbsMTTrackingId, 1), abs(AbsMTPositionX, 30), abs(AbsMTPositionY, 40),
+				abs(AbsMTPressure, TouchPressure), abs(AbsMTSlot, 0), abs(AbsMTPositionX, 11),
+				abs(AbsX, 11), abs(AbsY, 20), syn,
+			},
+		},
+		{
+			func() { ts.up(0) },
+			[]Event{abs(AbsMTTrackingId, -1), abs(AbsX, 30), abs(AbsY, 40), syn},
+		},
+		{
+			func() { ts.move(1, 30, 40) },
+			[]Event{abs(AbsX, 30), abs(AbsY, 40), syn},
+		},
+		{
+			func() { ts.up(1) },
+			[]Event{abs(AbsMTSlot, 1), abs(AbsMTTrackingId, -1), btn(0), syn},
+		},
+	}
+
+	for i, tt := range tests {
+		tt.fn()
+
+		if got := ts.frame(); !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("Frame %d:\nWant %v\nhave %v", i, tt.want, got)
+		}
+	}
+
+	ts.down(0, 0)
+	ts.down(0, 0)
+
+	if _, err := ts.down(0, 0); err != ErrNoSlot {
+		t.Fatalf("Third contact: Want ErrNoSlot, have %v", err)
+	}
+
+	if err := ts.Move(2, 0, 0); err == nil {
+		t.Fatalf("Move: Expected error for invalid slot")
+	}
+
+	if err := ts.Up(-1); err == nil {
+		t.Fatalf("Up: Expected error for invalid slot")
+	}
+}
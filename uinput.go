@@ -0,0 +1,405 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// <linux/uinput.h>
+
+// UinputNodes lists the locations of the uinput node,
+// in order of preference.
+var UinputNodes = []string{"/dev/uinput", "/dev/input/uinput"}
+
+// UinputMaxNameSize is the maximum length of a virtual device name,
+// including the terminating NUL byte.
+const UinputMaxNameSize = 80
+
+// VirtualConfig describes a virtual device to be created
+// through CreateVirtual.
+type VirtualConfig struct {
+	Name string // Device name, as reported by Device.Name.
+	Phys string // Optional physical path, as reported by Device.Path.
+	Id   Id     // Device identity.
+
+	// Properties holds the InputPropXXX properties to define
+	// for the device. This may be nil.
+	Properties Bitset
+
+	// Capabilities maps event types (EvXXX) onto the set of codes
+	// the device can emit for that type. E.g.: EvKeys -> {KeyA, KeyB}.
+	// The event types themselves are enabled implicitly.
+	// Types without codes, such as EvRepeat, are enabled by
+	// including them with an empty (or nil) bitset.
+	Capabilities map[int]Bitset
+
+	// Absolute provides the axis information for absolute axes.
+	// Axes listed here are enabled implicitly; axes enabled through
+	// Capabilities, but missing here, have a zero range.
+	Absolute map[int]AbsInfo
+
+	// EffectsMax is the number of force feedback effects the device
+	// can hold at once. It must be set if the device supports
+	// EvForceFeedback. See Device.ForceFeedbackCaps.
+	EffectsMax int
+
+	// ForceFeedback receives the force feedback requests which
+	// applications send to the device. It must be set if the device
+	// supports EvForceFeedback; the applications would otherwise
+	// hang while waiting for their effect uploads to complete.
+	ForceFeedback *FFHandler
+}
+
+// VirtualDevice is an input device created through /dev/uinput.
+// Events written to it are delivered to the rest of the system,
+// as if they came from real hardware.
+type VirtualDevice struct {
+	fd      *os.File
+	sysname string
+	once    sync.Once
+	ffDone  chan struct{} // Closed once pollFF returns; nil without force feedback.
+}
+
+// CreateVirtual creates a new virtual input device.
+// Creating virtual devices usually requires root access,
+// or write access to /dev/uinput.
+func CreateVirtual(cfg VirtualConfig) (*VirtualDevice, error) {
+	if len(cfg.Name) == 0 || len(cfg.Name) >= UinputMaxNameSize {
+		return nil, fmt.Errorf("uinput: device name must be 1-%d bytes", UinputMaxNameSize-1)
+	}
+
+	if _, ok := cfg.Capabilities[EvForceFeedback]; ok {
+		if cfg.EffectsMax <= 0 || cfg.ForceFeedback == nil {
+			return nil, errors.New("uinput: force feedback requires EffectsMax and a handler")
+		}
+	}
+
+	fd, err := openUinput()
+	if err != nil {
+		return nil, err
+	}
+
+	v := &VirtualDevice{fd: fd}
+
+	err = v.setup(&cfg)
+	if err != nil {
+		fd.Close()
+		return nil, err
+	}
+
+	err = v.ioctl(_UI_DEV_CREATE, 0)
+	if err != nil {
+		fd.Close()
+		return nil, err
+	}
+
+	var str [64]byte
+	if v.ioctl(_UI_GET_SYSNAME(len(str)), unsafe.Pointer(&str[0])) == nil {
+		v.sysname = cstring(str[:])
+	}
+
+	if cfg.ForceFeedback != nil {
+		v.handleFF(cfg.ForceFeedback)
+	}
+
+	return v, nil
+}
+
+// openUinput opens the first uinput node we can find.
+func openUinput() (*os.File, error) {
+	var err error
+
+	for _, node := range UinputNodes {
+		var fd *os.File
+
+		fd, err = os.OpenFile(node, os.O_RDWR, 0)
+		if err == nil {
+			return fd, nil
+		}
+
+		if !os.IsNotExist(err) {
+			break
+		}
+	}
+
+	return nil, err
+}
+
+// setup registers the device capabilities and identity.
+// This must happen before the device is created.
+func (v *VirtualDevice) setup(cfg *VirtualConfig) error {
+	for _, evtype := range sortedKeys(cfg.Capabilities) {
+		if evtype == EvSync {
+			continue
+		}
+
+		err := v.ioctl(_UI_SET_EVBIT, evtype)
+		if err != nil {
+			return err
+		}
+
+		set := cfg.Capabilities[evtype]
+		name := uinputBit(evtype)
+
+		if name == 0 {
+			continue
+		}
+
+		for code := 0; code < set.Len(); code++ {
+			if !set.Test(code) {
+				continue
+			}
+
+			err = v.ioctl(name, code)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, axis := range sortedKeys(cfg.Absolute) {
+		err := v.ioctl(_UI_SET_EVBIT, EvAbsolute)
+		if err == nil {
+			err = v.ioctl(_UI_SET_ABSBIT, axis)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	for prop := 0; prop < cfg.Properties.Len(); prop++ {
+		if cfg.Properties.Test(prop) {
+			err := v.ioctl(_UI_SET_PROPBIT, prop)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(cfg.Phys) > 0 {
+		phys := append([]byte(cfg.Phys), 0)
+		err := v.ioctl(_UI_SET_PHYS, unsafe.Pointer(&phys[0]))
+		if err != nil {
+			return err
+		}
+	}
+
+	var setup uinputSetup
+	setup.Id = cfg.Id
+	setup.EffectsMax = uint32(cfg.EffectsMax)
+	copy(setup.Name[:UinputMaxNameSize-1], cfg.Name)
+
+	err := v.ioctl(_UI_DEV_SETUP, unsafe.Pointer(&setup))
+	if err == syscall.EINVAL || err == syscall.ENOTTY {
+		// Kernels older than 4.5 only know the legacy interface.
+		return v.setupLegacy(cfg, &setup)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	for _, axis := range sortedKeys(cfg.Absolute) {
+		var abs uinputAbsSetup
+		abs.Code = uint16(axis)
+		abs.Info = cfg.Absolute[axis]
+
+		err = v.ioctl(_UI_ABS_SETUP, unsafe.Pointer(&abs))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setupLegacy registers the device identity and axes by writing
+// a struct uinput_user_dev, for kernels which lack UI_DEV_SETUP.
+func (v *VirtualDevice) setupLegacy(cfg *VirtualConfig, setup *uinputSetup) error {
+	var dev uinputUserDev
+	dev.Name = setup.Name
+	dev.Id = setup.Id
+	dev.EffectsMax = setup.EffectsMax
+
+	for axis, info := range cfg.Absolute {
+		if axis < 0 || axis >= AbsCount {
+			return fmt.Errorf("uinput: invalid axis 0x%02x", axis)
+		}
+
+		dev.AbsMax[axis] = info.Maximum
+		dev.AbsMin[axis] = info.Minimum
+		dev.AbsFuzz[axis] = info.Fuzz
+		dev.AbsFlat[axis] = info.Flat
+	}
+
+	size := int(unsafe.Sizeof(dev))
+	buf := (*(*[1<<31 - 1]byte)(unsafe.Pointer(&dev)))[:size]
+
+	n, err := v.fd.Write(buf)
+	if err == nil && n < size {
+		err = errors.New("uinput: short write")
+	}
+
+	return err
+}
+
+// ioctl performs an ioctl on the uinput node.
+func (v *VirtualDevice) ioctl(name uintptr, data interface{}) error {
+	return fileIoctl(v.fd, name, data)
+}
+
+// handleFF starts passing force feedback requests to the given handler.
+func (v *VirtualDevice) handleFF(h *FFHandler) {
+	v.ffDone = make(chan struct{})
+
+	go func() {
+		defer close(v.ffDone)
+		pollFF(v.fd, h)
+	}()
+}
+
+// Close destroys the virtual device and closes the uinput node.
+// It waits for a pending call to the force feedback handler to
+// return; so it must not be called from the handler itself.
+func (v *VirtualDevice) Close() (err error) {
+	v.once.Do(func() {
+		v.ioctl(_UI_DEV_DESTROY, 0)
+		err = v.fd.Close()
+
+		if v.ffDone != nil {
+			<-v.ffDone
+		}
+	})
+
+	return
+}
+
+// WriteEvents sends the given events through the virtual device.
+// Remember to end each group of changes with a SynReport.
+// The event timestamps are ignored; the kernel sets its own.
+func (v *VirtualDevice) WriteEvents(list ...Event) error {
+	return writeEvents(v.fd, list)
+}
+
+// Emit sends a single event through the virtual device.
+func (v *VirtualDevice) Emit(evtype, code int, value int32) error {
+	var e Event
+	e.Type = uint16(evtype)
+	e.Code = uint16(code)
+	e.Value = value
+	return v.WriteEvents(e)
+}
+
+// Sync sends a SynReport, marking the end of a group of changes.
+func (v *VirtualDevice) Sync() error {
+	return v.Emit(EvSync, SynReport, 0)
+}
+
+// SysName returns the name the kernel assigned to the device.
+// For example: input42. This returns an empty string if the
+// kernel does not support the query (Linux 3.15 and up do).
+func (v *VirtualDevice) SysName() string {
+	return v.sysname
+}
+
+// SysPath returns the sysfs directory describing the device.
+// For example: /sys/devices/virtual/input/input42
+func (v *VirtualDevice) SysPath() string {
+	if len(v.sysname) == 0 {
+		return ""
+	}
+
+	return "/sys/devices/virtual/input/" + v.sysname
+}
+
+// Nodes returns the event nodes through which the device can be
+// read. For example: /dev/input/event7. Most devices have only one.
+func (v *VirtualDevice) Nodes() ([]string, error) {
+	dir := v.SysPath()
+	if len(dir) == 0 {
+		return nil, errors.New("uinput: device name unknown")
+	}
+
+	list, err := filepath.Glob(filepath.Join(dir, "event*"))
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range list {
+		list[i] = filepath.Join(InputDir, filepath.Base(list[i]))
+	}
+
+	sort.Sort(byEventNumber(list))
+	return list, nil
+}
+
+// uinputBit returns the ioctl used to enable codes of the
+// given event type, or 0 if the type has no codes.
+func uinputBit(evtype int) uintptr {
+	switch evtype {
+	case EvKeys:
+		return _UI_SET_KEYBIT
+	case EvRelative:
+		return _UI_SET_RELBIT
+	case EvAbsolute:
+		return _UI_SET_ABSBIT
+	case EvMisc:
+		return _UI_SET_MSCBIT
+	case EvSwitch:
+		return _UI_SET_SWBIT
+	case EvLed:
+		return _UI_SET_LEDBIT
+	case EvSound:
+		return _UI_SET_SNDBIT
+	case EvForceFeedback:
+		return _UI_SET_FFBIT
+	}
+
+	return 0
+}
+
+// sortedKeys returns the keys of the given map in ascending order.
+func sortedKeys[T any](m map[int]T) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Ints(keys)
+	return keys
+}
+
+// uinputSetup mirrors struct uinput_setup.
+type uinputSetup struct {
+	Id         Id
+	Name       [UinputMaxNameSize]byte
+	EffectsMax uint32
+}
+
+// uinputAbsSetup mirrors struct uinput_abs_setup.
+type uinputAbsSetup struct {
+	Code uint16
+	_    uint16
+	Info AbsInfo
+}
+
+// uinputUserDev mirrors struct uinput_user_dev.
+type uinputUserDev struct {
+	Name       [UinputMaxNameSize]byte
+	Id         Id
+	EffectsMax uint32
+	AbsMax     [AbsCount]int32
+	AbsMin     [AbsCount]int32
+	AbsFuzz    [AbsCount]int32
+	AbsFlat    [AbsCount]int32
+}
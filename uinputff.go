@@ -0,0 +1,222 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// Event type and codes used by uinput to signal force feedback requests.
+const (
+	evUinput   = 0x0101
+	uiFFUpload = 1
+	uiFFErase  = 2
+)
+
+// FFHandler receives the force feedback requests which applications
+// send to a virtual device. Every function is optional. They are all
+// called from the same goroutine, one at a time.
+//
+// Use ForwardEffects to pass all requests on to a physical device.
+type FFHandler struct {
+	// Upload is called when an application uploads a new effect,
+	// or updates an existing one. In the latter case, old holds the
+	// previous version of the effect; otherwise it is nil. The effect
+	// id has already been assigned by the kernel. Returning an error
+	// makes the upload fail.
+	Upload func(effect, old *Effect) error
+
+	// Erase is called when an application deletes an effect.
+	// Returning an error makes the deletion fail.
+	Erase func(id int16) error
+
+	// Play is called when an application starts or stops an effect.
+	// A count of 0 means stop. Anything else is the number of times
+	// the effect should be played.
+	Play func(id int16, count int32)
+
+	// Gain is called when an application changes the force feedback
+	// gain. The value is in the range 0-0xffff.
+	// See Device.SetEffectGain.
+	Gain func(value int32)
+
+	// AutoCenter is called when an application changes the autocenter
+	// factor. The value is in the range 0-0xffff.
+	// See Device.SetEffectAutoCenter.
+	AutoCenter func(value int32)
+}
+
+// ForwardEffects returns a handler which passes all force feedback
+// requests on to the given device. This is what a virtual device
+// needs when it stands in for a physical one.
+//
+// The effect ids used by the virtual and the physical device need not
+// be the same. The handler keeps track of which is which.
+func ForwardEffects(dev *Device) *FFHandler {
+	ids := make(map[int16]int16)
+
+	return &FFHandler{
+		Upload: func(effect, old *Effect) error {
+			e := *effect
+			e.Id = -1
+
+			if id, ok := ids[effect.Id]; ok {
+				e.Id = id
+			}
+
+			if !dev.SetEffects(&e) {
+				return syscall.EIO
+			}
+
+			ids[effect.Id] = e.Id
+			return nil
+		},
+
+		Erase: func(id int16) error {
+			e, ok := ids[id]
+			if !ok {
+				return nil
+			}
+
+			delete(ids, id)
+
+			if !dev.UnsetEffects(&Effect{Id: e}) {
+				return syscall.EIO
+			}
+
+			return nil
+		},
+
+		Play: func(id int16, count int32) {
+			if e, ok := ids[id]; ok {
+				dev.send(Event{Type: EvForceFeedback, Code: uint16(e), Value: count})
+			}
+		},
+
+		Gain: func(value int32) {
+			dev.send(Event{Type: EvForceFeedback, Code: FFGain, Value: value})
+		},
+
+		AutoCenter: func(value int32) {
+			dev.send(Event{Type: EvForceFeedback, Code: FFAutoCenter, Value: value})
+		},
+	}
+}
+
+// pollFF reads the requests sent to a virtual device and passes
+// the force feedback related ones to the given handler.
+// It returns once the uinput node has been closed.
+func pollFF(fd *os.File, h *FFHandler) {
+	buf := make([]Event, eventBufferSize)
+	size := int(unsafe.Sizeof(buf[0]))
+	raw := unsafe.Slice((*byte)(unsafe.Pointer(&buf[0])), len(buf)*size)
+
+	for {
+		n, err := fd.Read(raw)
+		if err != nil {
+			return
+		}
+
+		for _, e := range buf[:n/size] {
+			switch {
+			case e.Type == evUinput && e.Code == uiFFUpload:
+				h.upload(fd, uint32(e.Value))
+
+			case e.Type == evUinput && e.Code == uiFFErase:
+				h.erase(fd, uint32(e.Value))
+
+			case e.Type == EvForceFeedback:
+				h.control(e.Code, e.Value)
+			}
+		}
+	}
+}
+
+// upload completes the given effect upload request.
+func (h *FFHandler) upload(fd *os.File, request uint32) {
+	var req uinputFFUpload
+	req.RequestId = request
+
+	if fileIoctl(fd, _UI_BEGIN_FF_UPLOAD, unsafe.Pointer(&req)) != nil {
+		return
+	}
+
+	if h.Upload != nil {
+		var old *Effect
+		if req.Old.Type != 0 {
+			old = &req.Old
+		}
+
+		req.Retval = ffRetval(h.Upload(&req.Effect, old))
+	}
+
+	fileIoctl(fd, _UI_END_FF_UPLOAD, unsafe.Pointer(&req))
+}
+
+// erase completes the given effect erase request.
+func (h *FFHandler) erase(fd *os.File, request uint32) {
+	var req uinputFFErase
+	req.RequestId = request
+
+	if fileIoctl(fd, _UI_BEGIN_FF_ERASE, unsafe.Pointer(&req)) != nil {
+		return
+	}
+
+	if h.Erase != nil {
+		req.Retval = ffRetval(h.Erase(int16(req.EffectId)))
+	}
+
+	fileIoctl(fd, _UI_END_FF_ERASE, unsafe.Pointer(&req))
+}
+
+// control handles effect playback, gain and autocenter requests.
+func (h *FFHandler) control(code uint16, value int32) {
+	switch code {
+	case FFGain:
+		if h.Gain != nil {
+			h.Gain(value)
+		}
+
+	case FFAutoCenter:
+		if h.AutoCenter != nil {
+			h.AutoCenter(value)
+		}
+
+	default:
+		if h.Play != nil {
+			h.Play(int16(code), value)
+		}
+	}
+}
+
+// ffRetval turns the given error into the negative errno
+// value the kernel expects in an upload or erase request.
+func ffRetval(err error) int32 {
+	if err == nil {
+		return 0
+	}
+
+	if errno, ok := err.(syscall.Errno); ok {
+		return -int32(errno)
+	}
+
+	return -int32(syscall.EINVAL)
+}
+
+// uinputFFUpload mirrors struct uinput_ff_upload.
+type uinputFFUpload struct {
+	RequestId uint32
+	Retval    int32
+	Effect    Effect
+	Old       Effect
+}
+
+// uinputFFErase mirrors struct uinput_ff_erase.
+type uinputFFErase struct {
+	RequestId uint32
+	Retval    int32
+	EffectId  uint32
+}
@@ -0,0 +1,61 @@
+// This file is subject to a 1-clause BSD license.
+// Its contents can be found in the enclosed LICENSE file.
+
+package evdev
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestVirtualCloseFF(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer w.Close()
+
+	played := make(chan int32, 1)
+	v := &VirtualDevice{fd: r}
+
+	v.handleFF(&FFHandler{
+		Play: func(id int16, count int32) {
+			played <- count
+		},
+	})
+
+	err = writeEvents(w, []Event{{Type: EvForceFeedback, Code: 3, Value: 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case count := <-played:
+		if count != 2 {
+			t.Fatalf("Play: Want 2, have %d", count)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Play: not called")
+	}
+
+	// The handler is idle; Close must wake it up and wait for it.
+	closed := make(chan struct{})
+	go func() {
+		v.Close()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatalf("Close: blocked")
+	}
+
+	select {
+	case <-v.ffDone:
+	default:
+		t.Fatalf("Close: returned before the handler did")
+	}
+}